@@ -0,0 +1,58 @@
+// Copyright (c) 2023 - 2024, nuxen and the seasonpackarr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/nuxencs/seasonpackarr/internal/buildinfo"
+	"github.com/nuxencs/seasonpackarr/internal/config"
+	"github.com/nuxencs/seasonpackarr/internal/http"
+	"github.com/nuxencs/seasonpackarr/internal/janitor"
+	"github.com/nuxencs/seasonpackarr/internal/logger"
+
+	"github.com/autobrr/go-qbittorrent"
+	"github.com/spf13/cobra"
+)
+
+// cleanupCmd represents the cleanup command
+var cleanupCmd = &cobra.Command{
+	Use:   "cleanup",
+	Short: "Remove season pack folders that were never claimed by a torrent",
+	Example: `  seasonpackarr cleanup --dry-run
+  seasonpackarr cleanup --config "/path/to/config"`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg := config.New(configPath, buildinfo.Version)
+		log := logger.New(cfg.Config)
+
+		folders, err := janitor.NewStore(filepath.Join(cfg.DataDir(), janitor.FoldersFile))
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+
+		history := janitor.NewHistory(filepath.Join(cfg.DataDir(), janitor.HistoryFile))
+
+		jan := janitor.New(log, cfg, folders, history, func(ctx context.Context, clientName string) ([]qbittorrent.Torrent, error) {
+			return http.ListTorrents(ctx, cfg, clientName)
+		})
+
+		results, err := jan.Run(context.Background(), dryRun)
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+
+		if len(results) == 0 {
+			fmt.Println("No stale folders found")
+			return
+		}
+
+		for _, result := range results {
+			fmt.Printf("%s: %s (%s)\n", result.Action, result.Folder.Path, result.Reason)
+		}
+	},
+}
@@ -0,0 +1,50 @@
+// Copyright (c) 2023 - 2024, nuxen and the seasonpackarr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/nuxencs/seasonpackarr/internal/buildinfo"
+	"github.com/nuxencs/seasonpackarr/internal/config"
+
+	"github.com/spf13/cobra"
+)
+
+// formatCmd represents the format command
+var formatCmd = &cobra.Command{
+	Use:   "format",
+	Short: "Preview the season pack folder name for a specified release",
+	Example: `  seasonpackarr test format “Series S01 1080p WEB-DL DDP 5.1 H.264-RlsGrp”
+  seasonpackarr test format “Series S01 1080p WEB-DL DDP 5.1 H.264-RlsGrp” --client "default" --config "/path/to/config"`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) == 0 {
+			fmt.Println("Please provide a release name")
+			return
+		}
+
+		rlsName = args[0]
+
+		cfg := config.New(configPath, buildinfo.Version)
+
+		if _, ok := cfg.Config.Clients[clientName]; !ok && len(clientName) != 0 {
+			fmt.Printf("Client %q not found in config\n", clientName)
+			return
+		}
+
+		folderNamer, err := cfg.FolderNamer(clientName)
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+
+		folderName, err := folderNamer.Format(rlsName)
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+
+		fmt.Println(folderName)
+	},
+}
@@ -17,6 +17,7 @@ var (
 	host        string
 	port        int
 	apiKey      string
+	dryRun      bool
 )
 
 var rootCmd = &cobra.Command{
@@ -35,14 +36,17 @@ For more information and examples, visit https://github.com/nuxencs/seasonpackar
 
 func init() {
 	startCmd.Flags().StringVarP(&configPath, "config", "c", "", "path to the configuration directory")
+	formatCmd.Flags().StringVarP(&configPath, "config", "c", "", "path to the configuration directory")
+	cleanupCmd.Flags().StringVarP(&configPath, "config", "c", "", "path to the configuration directory")
+	cleanupCmd.Flags().BoolVar(&dryRun, "dry-run", false, "only list the folders that would be removed")
 
 	testCmd.PersistentFlags().StringVarP(&clientName, "client", "n", "", "name of the client you want to test")
 	testCmd.PersistentFlags().StringVarP(&host, "host", "i", "127.0.0.1", "host used by seasonpackarr")
 	testCmd.PersistentFlags().IntVarP(&port, "port", "p", 42069, "port used by seasonpackarr")
 	testCmd.PersistentFlags().StringVarP(&apiKey, "api", "a", "", "api key used by seasonpackarr")
 
-	rootCmd.AddCommand(genTokenCmd, startCmd, testCmd, versionCmd)
-	testCmd.AddCommand(packCmd, parseCmd)
+	rootCmd.AddCommand(cleanupCmd, genTokenCmd, startCmd, testCmd, versionCmd)
+	testCmd.AddCommand(packCmd, parseCmd, formatCmd)
 }
 
 func Execute() {
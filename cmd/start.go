@@ -7,15 +7,18 @@ import (
 	"context"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"syscall"
 
 	"github.com/nuxencs/seasonpackarr/internal/buildinfo"
 	"github.com/nuxencs/seasonpackarr/internal/config"
 	"github.com/nuxencs/seasonpackarr/internal/http"
+	"github.com/nuxencs/seasonpackarr/internal/janitor"
 	"github.com/nuxencs/seasonpackarr/internal/logger"
 	"github.com/nuxencs/seasonpackarr/internal/notification"
 	"github.com/nuxencs/seasonpackarr/pkg/errors"
 
+	"github.com/autobrr/go-qbittorrent"
 	"github.com/spf13/cobra"
 )
 
@@ -40,7 +43,20 @@ var startCmd = &cobra.Command{
 		// init notification sender
 		noti := notification.NewDiscordSender(log, cfg)
 
-		srv := http.NewServer(log, cfg, noti)
+		// init cleanup of stale season pack folders
+		folders, err := janitor.NewStore(filepath.Join(cfg.DataDir(), janitor.FoldersFile))
+		if err != nil {
+			log.Fatal().Err(err).Msg("error loading tracked folders")
+		}
+
+		history := janitor.NewHistory(filepath.Join(cfg.DataDir(), janitor.HistoryFile))
+
+		jan := janitor.New(log, cfg, folders, history, func(ctx context.Context, clientName string) ([]qbittorrent.Torrent, error) {
+			return http.ListTorrents(ctx, cfg, clientName)
+		})
+		go jan.Start(context.Background())
+
+		srv := http.NewServer(log, cfg, noti, folders)
 
 		log.Info().Msgf("Starting seasonpackarr")
 		log.Info().Msgf("Version: %s", buildinfo.Version)
@@ -0,0 +1,145 @@
+// Copyright (c) 2023 - 2024, nuxen and the seasonpackarr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package config
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/nuxencs/seasonpackarr/internal/domain"
+	"github.com/nuxencs/seasonpackarr/internal/logger"
+	"github.com/nuxencs/seasonpackarr/pkg/errors"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// parseGroupAliasesFile reads a group alias file where every line contains one set of comma separated
+// release groups that should be treated as the same group. Empty lines and lines starting with # are ignored.
+func parseGroupAliasesFile(filePath string) ([][]string, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not open group aliases file: %s", filePath)
+	}
+	defer f.Close()
+
+	var groupAliases [][]string
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var aliases []string
+		for _, alias := range strings.Split(line, ",") {
+			if alias = strings.TrimSpace(alias); len(alias) != 0 {
+				aliases = append(aliases, alias)
+			}
+		}
+
+		if len(aliases) > 1 {
+			groupAliases = append(groupAliases, aliases)
+		}
+	}
+
+	if err = scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "could not read group aliases file: %s", filePath)
+	}
+
+	return groupAliases, nil
+}
+
+// loadGroupAliasesFile replaces the group aliases loaded from groupAliasesFile. The caller needs to hold c.m.
+func (c *AppConfig) loadGroupAliasesFile(log logger.Logger) {
+	filePath := c.Config.FuzzyMatching.GroupAliasesFile
+	if len(filePath) == 0 {
+		c.fileGroupAliases = nil
+		return
+	}
+
+	groupAliases, err := parseGroupAliasesFile(filePath)
+	if err != nil {
+		log.Error().Err(err).Msg("error loading group aliases file, keeping previous aliases")
+		return
+	}
+
+	c.fileGroupAliases = groupAliases
+	log.Debug().Msgf("loaded %d group alias sets from %s", len(groupAliases), filePath)
+}
+
+// watchGroupAliasesFile reloads the group aliases file whenever it changes. The parent directory is watched
+// instead of the file itself, so editors that replace the file on save are handled as well.
+func (c *AppConfig) watchGroupAliasesFile(log logger.Logger) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		log.Error().Err(err).Msg("error creating group aliases file watcher")
+		return
+	}
+
+	c.m.Lock()
+	c.loadGroupAliasesFile(log)
+	c.m.Unlock()
+
+	var watchedDir string
+
+	go func() {
+		defer watcher.Close()
+
+		for {
+			c.m.Lock()
+			filePath := c.Config.FuzzyMatching.GroupAliasesFile
+			c.m.Unlock()
+
+			if dir := filepath.Dir(filePath); len(filePath) != 0 && dir != watchedDir {
+				if len(watchedDir) != 0 {
+					_ = watcher.Remove(watchedDir)
+				}
+
+				if err := watcher.Add(dir); err != nil {
+					log.Error().Err(err).Msgf("error watching group aliases directory: %s", dir)
+				}
+				watchedDir = dir
+			}
+
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+
+				if filepath.Clean(event.Name) != filepath.Clean(filePath) ||
+					!event.Has(fsnotify.Write) && !event.Has(fsnotify.Create) && !event.Has(fsnotify.Remove) {
+					continue
+				}
+
+				c.m.Lock()
+				c.loadGroupAliasesFile(log)
+				c.m.Unlock()
+
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				log.Error().Err(err).Msg("error watching group aliases file")
+
+			case <-c.aliasesChanged:
+			}
+		}
+	}()
+}
+
+// FuzzyMatching returns a copy of the fuzzy matching config with the group aliases from
+// groupAliasesFile merged into the ones defined in the config.
+func (c *AppConfig) FuzzyMatching() domain.FuzzyMatching {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	fuzzyMatching := c.Config.FuzzyMatching
+	fuzzyMatching.GroupAliases = append(append([][]string{}, fuzzyMatching.GroupAliases...), c.fileGroupAliases...)
+
+	return fuzzyMatching
+}
@@ -0,0 +1,72 @@
+// Copyright (c) 2023 - 2024, nuxen and the seasonpackarr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nuxencs/seasonpackarr/internal/domain"
+	"github.com/nuxencs/seasonpackarr/internal/logger"
+	"github.com/nuxencs/seasonpackarr/internal/release"
+
+	"github.com/moistari/rls"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_AppConfig_FuzzyMatching(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "groups.txt")
+	require.NoError(t, os.WriteFile(filePath, []byte("# aliases\nRlsGrp, FileRlsGrp\n\nlonely\n"), 0o644))
+
+	c := &AppConfig{
+		Config: &domain.Config{
+			FuzzyMatching: domain.FuzzyMatching{
+				GroupAliases:     [][]string{{"RlsGrp", "ConfigRlsGrp"}},
+				GroupAliasesFile: filePath,
+			},
+		},
+	}
+	c.loadGroupAliasesFile(logger.New(c.Config))
+
+	fuzzyMatching := c.FuzzyMatching()
+	assert.Equal(t, [][]string{{"RlsGrp", "ConfigRlsGrp"}, {"RlsGrp", "FileRlsGrp"}}, fuzzyMatching.GroupAliases)
+	assert.Equal(t, [][]string{{"RlsGrp", "ConfigRlsGrp"}}, c.Config.FuzzyMatching.GroupAliases,
+		"merging must not modify the config")
+
+	tests := []struct {
+		name       string
+		clientName string
+		want       domain.CompareInfo
+	}{
+		{
+			name:       "aliased_in_config",
+			clientName: "Series Title S01E01 1080p AMZN WEB-DL DDP 5.1 H.264-ConfigRlsGrp",
+			want:       domain.CompareInfo{StatusCode: domain.StatusSuccessfulMatch},
+		},
+		{
+			name:       "aliased_in_file",
+			clientName: "Series Title S01E01 1080p AMZN WEB-DL DDP 5.1 H.264-FileRlsGrp",
+			want:       domain.CompareInfo{StatusCode: domain.StatusSuccessfulMatch},
+		},
+		{
+			name:       "not_aliased",
+			clientName: "Series Title S01E01 1080p AMZN WEB-DL DDP 5.1 H.264-lonely",
+			want: domain.CompareInfo{
+				StatusCode:   domain.StatusRlsGrpMismatch,
+				RejectValueA: "RlsGrp",
+				RejectValueB: "lonely",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requestRls := rls.ParseString("Series Title S01 1080p AMZN WEB-DL DDP 5.1 H.264-RlsGrp")
+			clientRls := rls.ParseString(tt.clientName)
+
+			assert.Equal(t, tt.want, release.CheckCandidates(requestRls, clientRls, fuzzyMatching))
+		})
+	}
+}
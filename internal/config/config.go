@@ -19,6 +19,7 @@ import (
 
 	"github.com/nuxencs/seasonpackarr/internal/domain"
 	"github.com/nuxencs/seasonpackarr/internal/logger"
+	"github.com/nuxencs/seasonpackarr/internal/utils"
 	"github.com/nuxencs/seasonpackarr/pkg/errors"
 
 	"github.com/fsnotify/fsnotify"
@@ -78,6 +79,80 @@ clients:
     #
     preImportPath: ""
 
+    # Episode Extensions
+    # Overrides the global episode extensions for this client
+    #
+    # Optional
+    #
+    # episodeExtensions: [ "mkv", "mp4" ]
+
+    # Folder Template
+    # Overrides the global folder template for this client
+    #
+    # Optional
+    #
+    # folderTemplate: '{{"{{"}}.Title}}.S{{"{{"}}printf "%02d" .Season}}.{{"{{"}}.Resolution}}.{{"{{"}}.Source}}-{{"{{"}}.Group}}'
+
+    # Link Strategies
+    # Strategies that are tried in order to link the episodes into the preImportPath
+    # hardlink and reflink need the download folder and the preImportPath to be on the same filesystem and are
+    # skipped right away if they aren't, symlink and copy work across filesystems
+    # reflink is only supported on Linux with filesystems like btrfs or XFS
+    #
+    # Default: [ "hardlink" ]
+    #
+    # linkStrategies: [ "hardlink", "reflink", "copy" ]
+
+    # Path Mappings
+    # Translate the paths reported by qBittorrent to the paths seasonpackarr sees, e.g. if both run in containers with
+    # different mounts. The mapping with the longest matching remote path is used
+    #
+    # Optional
+    #
+    # pathMappings:
+    #   - remote: "/downloads"
+    #     local: "/data/torrents"
+
+    # Folder Permissions
+    # Mode, owner and group of every folder seasonpackarr creates, e.g. if Sonarr runs as a different user and needs to
+    # rename the imported season folder. The owner and group are also applied to copied and reflinked files
+    # user and group can be names or numeric ids, setgid makes new files in the folders inherit their group
+    #
+    # Optional
+    #
+    # dirMode: "0775"
+    # user: "1000"
+    # group: "media"
+    # setgid: true
+
+    # Inject
+    # Adds the season pack to this client after the episodes were linked with the parse endpoint, instead of leaving
+    # that to autobrr. The pack is saved to the preImportPath and rechecked, the result of the recheck is tagged and
+    # reported as described in the recheck section
+    #
+    # Optional
+    #
+    # inject:
+    #   enabled: false
+    #   category: "tv"
+    #   tags: [ "seasonpackarr" ]
+    #   # Minutes to wait for the recheck to finish
+    #   recheckTimeout: 30
+
+    # Filters
+    # Limits the torrents of this client whose episodes are linked into season packs, e.g. to skip movies, music and
+    # unfinished downloads on large clients. A torrent needs to match one of the categories, one of the tags and one of
+    # the save path prefixes, unset filters match every torrent. Save path prefixes are paths as the client sees them.
+    # Packs that were moved out of the filters are still found by their infohash or, with sync enabled, by name
+    #
+    # Optional
+    #
+    # filters:
+    #   categories: [ "tv", "tv-uhd" ]
+    #   tags: [ "tracker" ]
+    #   savePathPrefixes: [ "/downloads/tv" ]
+    #   completedOnly: true
+
   # Below you can find an example on how to define a second qBittorrent client
   # If you want to define even more clients just copy this segment and adjust the values accordingly
   #
@@ -91,6 +166,8 @@ clients:
   #  password: "example"
   #
   #  preImportPath: ""
+  #
+  #  episodeExtensions: [ "mkv" ]
 
 # seasonpackarr logs file
 # If not defined, logs to stdout
@@ -145,6 +222,221 @@ logLevel: "DEBUG"
 #
 # parseTorrentFile: false
 
+# Completion Threshold
+# Sets the percentage of the season pack that must be complete after the client rechecks the hardlinked files
+# Pieces that span a missing episode can never complete, so this is calculated from the pieces and not the file sizes
+# In this example 90% of the season pack must be complete, otherwise the request is rejected before hardlinking
+# Only works together with parseTorrentFile, 0 disables the check
+#
+# Default: 0
+#
+# completionThreshold: 0.9
+
+# Metadata Timeout
+# Sets the number of seconds to wait for the client to receive the metadata of a magnet link sent to the parse endpoint
+#
+# Default: 60
+#
+# metadataTimeout: 60
+
+# Torrent URL
+# Torrent files passed as torrentUrl to the parse endpoint are only downloaded from public addresses, so the endpoint
+# can't be used to reach services in your network
+#
+torrentUrl:
+  # Hosts that may resolve to local or private addresses, e.g. an indexer proxy running next to seasonpackarr
+  #
+  # Default: []
+  #
+  # allowedHosts: [ "prowlarr", "192.168.1.10" ]
+
+# Conflict Policy
+# Decides what happens if a file in the season pack folder already exists with different content
+# Files that already exist with the same content, e.g. from a retry or the same pack announced on multiple trackers,
+# always count as linked. Options: "skip", "overwrite"
+#
+# Default: "skip"
+#
+# conflictPolicy: "skip"
+
+# Incomplete Policy
+# Decides what happens with matching torrents in your client that didn't finish downloading yet, only fully downloaded
+# files are ever linked. "skip" ignores them right away, "wait" waits up to incompleteTimeout seconds for them to
+# finish. Options: "skip", "wait"
+#
+# Default: "skip"
+#
+# incompletePolicy: "skip"
+
+# Incomplete Timeout
+# Sets the number of seconds a request waits for incomplete torrents to finish, shared by all torrents of the request
+#
+# Default: 60
+#
+# incompleteTimeout: 60
+
+# Separate Languages
+# Toggles separating releases by their language tags, so only releases with the same languages are considered as
+# candidates for a season pack, e.g. GERMAN episodes will never be looked at for an English season pack
+#
+# Default: false
+#
+# separateLanguages: false
+
+# Episode Extensions
+# File extensions of video files that are treated as episodes, both in your client and in the season pack torrent
+# Can be overridden for every client
+#
+# Default: [ "mkv" ]
+#
+# episodeExtensions: [ "mkv", "mp4", "avi" ]
+
+# Folder Naming
+# Decides how the season pack folder is named if parseTorrentFile is disabled
+# The template builds the name from the parsed announce name, if it's empty the announce name is used as is
+# Afterward the rules are applied in order, every match of the regex is replaced with the replacement
+# Releases of exempt groups are never adjusted
+#
+folderNaming:
+  # Optional
+  #
+  # Fields: Name, Title, Year, Season, Resolution, Source, Service, HDR, Audio, Channels, Codec, Cut, Edition,
+  # Language, Other, Group
+  #
+  # template: '{{"{{"}}.Title}}.S{{"{{"}}printf "%02d" .Season}}.{{"{{"}}.Resolution}}.{{"{{"}}.Service}}.{{"{{"}}.Source}}-{{"{{"}}.Group}}'
+
+  # Default: remove illegal characters, replace spaces with periods, fix the audio naming and collapse periods
+  #
+  # rules:
+  #   - match: '[\\/:"*?<>|]'
+  #     replace: ""
+  #   - match: " "
+  #     replace: "."
+  #   - match: '(?i)(AAC|DDP)\.(\d\.\d)'
+  #     replace: "$1$2"
+  #   - match: '\.+'
+  #     replace: "."
+
+  # Default: [ "ZR" ]
+  #
+  # exemptGroups: [ "ZR" ]
+
+# Companion Files
+# Toggles linking of subtitles, NFOs and other extras that are stored alongside the episode files
+# Only files matching one of the include globs and none of the exclude globs are linked
+#
+companionFiles:
+  # Default: false
+  #
+  enabled: false
+
+  # Default: [ "*.srt", "*.ass", "*.ssa", "*.sub", "*.idx", "*.nfo" ]
+  #
+  # include: [ "*.srt", "*.ass", "*.ssa", "*.sub", "*.idx", "*.nfo" ]
+
+  # Optional
+  #
+  # exclude: [ "*sample*" ]
+
+# Verify Pieces
+# Toggles verifying the episodes in your client against the pieces of the season pack before they are hardlinked,
+# which catches different encodes with the same size, only works together with parseTorrentFile
+# "full" hashes every piece of an episode, "sample" only hashes a number of pieces spread over the episode
+#
+verifyPieces:
+  # Default: false
+  #
+  enabled: false
+
+  # Default: "sample"
+  #
+  # Options: "full", "sample"
+  #
+  # mode: "sample"
+
+  # Default: 8
+  #
+  # samples: 8
+
+# Cleanup
+# Toggles removing season pack folders created by seasonpackarr that were never claimed by a torrent in the client,
+# e.g. because the pack was never grabbed. Removed folders are logged to history.jsonl next to the config
+# Run "seasonpackarr cleanup --dry-run" to list the folders that would be removed
+#
+cleanup:
+  # Default: false
+  #
+  enabled: false
+
+  # Only log the folders that would be removed
+  #
+  # Default: false
+  #
+  # dryRun: false
+
+  # Hours after which an unclaimed folder is removed
+  #
+  # Default: 72
+  #
+  # maxAge: 72
+
+  # Minutes between two cleanup runs
+  #
+  # Default: 60
+  #
+  # interval: 60
+
+# Recheck
+# Toggles watching the recheck of every season pack prepared with the parse endpoint. Packs that verified completely
+# and packs with missing pieces are tagged, and a notification is sent for each outcome. Injected packs are always
+# watched, for up to the recheckTimeout of their client
+#
+recheck:
+  # Default: false
+  #
+  enabled: false
+
+  # Tag added to packs that verified completely, leave empty to not add a tag
+  #
+  # Default: "seasonpackarr-verified"
+  #
+  # verifiedTag: "seasonpackarr-verified"
+
+  # Tag added to packs with missing pieces, leave empty to not add a tag
+  #
+  # Default: "seasonpackarr-incomplete"
+  #
+  # incompleteTag: "seasonpackarr-incomplete"
+
+  # What to do with packs with missing pieces, "resume" downloads the missing pieces
+  #
+  # Default: "none"
+  #
+  # Options: "none", "pause", "resume"
+  #
+  # incompleteAction: "none"
+
+  # Minutes to wait for the client to add and recheck a pack
+  #
+  # Default: 30
+  #
+  # timeout: 30
+
+# Sync
+# Toggles keeping the torrent list of every client up to date in the background with the sync API of qBittorrent,
+# which only transfers what changed. Requests then never wait for the full torrent list, which helps on large clients
+#
+sync:
+  # Default: false
+  #
+  enabled: false
+
+  # Seconds between two syncs
+  #
+  # Default: 10
+  #
+  # interval: 10
+
 # Fuzzy Matching
 # You can decide for which criteria the matching should be less strict, e.g. repack status and HDR format
 #
@@ -163,6 +455,72 @@ fuzzyMatching:
   #
   simplifyHdrCompare: false
 
+  # Simplify Audio Compare
+  # Toggle simplification of audio formats for comparing, e.g. DTS-HD MA will be treated the same as DTS. Atmos is
+  # always ignored, so DDP Atmos and DDPA match DDP either way
+  #
+  # Default: false
+  #
+  # simplifyAudioCompare: false
+
+  # Skip Channels Compare
+  # Toggle comparing of the audio channels of a release, e.g. 5.1 episodes will be treated the same as 2.0 ones
+  #
+  # Default: false
+  #
+  # skipChannelsCompare: false
+
+  # Simplify Codec Compare
+  # Toggle simplification of video codecs for comparing, e.g. x264 and AVC will be treated the same as H.264
+  #
+  # Default: false
+  #
+  # simplifyCodecCompare: false
+
+  # Language Compare
+  # Decides how the language tags of a release are compared, e.g. GERMAN.DL, MULTi or NORDiC
+  # "exact" requires the same languages, "subset" allows the languages of one release to be contained in the other
+  # and "ignore" skips comparing languages
+  #
+  # Default: "exact"
+  #
+  # Options: "exact", "subset", "ignore"
+  #
+  # languageCompare: "exact"
+
+  # Ignore Missing Streaming Service
+  # Toggle comparing of the streaming service when only one of the releases has one, e.g. a pack without AMZN will be
+  # treated the same as episodes tagged with AMZN
+  #
+  # Default: false
+  #
+  # ignoreMissingStreamingService: false
+
+  # Streaming Service Aliases
+  # Sets of streaming service tags that should be treated as the same service
+  #
+  # Optional
+  #
+  # streamingServiceAliases:
+  #   - [ "ATVP", "APTV" ]
+
+  # Group Aliases
+  # Sets of release groups that should be treated as the same group, e.g. when a group releases episodes and packs
+  # under different tags. Groups are compared the way they are parsed from the release name
+  #
+  # Optional
+  #
+  # groupAliases:
+  #   - [ "NTb", "Mirror" ]
+
+  # Group Aliases File
+  # Path to a local file with additional group aliases, the file is reloaded automatically when it changes
+  # Every line contains one set of comma separated release groups, lines starting with # are ignored
+  #
+  # Optional
+  #
+  # groupAliasesFile: ""
+
 # API Token
 # If not defined, removes api authentication
 #
@@ -276,7 +634,11 @@ type Config interface {
 
 type AppConfig struct {
 	Config *domain.Config
-	m      *sync.Mutex
+	m      sync.Mutex
+
+	fileGroupAliases [][]string
+	aliasesChanged   chan struct{}
+	folderNamers     map[string]*utils.FolderNamer
 }
 
 func New(configPath string, version string) *AppConfig {
@@ -288,7 +650,7 @@ func New(configPath string, version string) *AppConfig {
 	}
 
 	c := &AppConfig{
-		m: new(sync.Mutex),
+		aliasesChanged: make(chan struct{}, 1),
 	}
 	c.defaults()
 	c.Config = &domain.Config{
@@ -307,11 +669,38 @@ func New(configPath string, version string) *AppConfig {
 		if _, err := os.Stat(client.PreImportPath); errors.Is(err, fs.ErrNotExist) {
 			log.Fatalf("preImportPath for client %q doesn't exist, please make sure you entered the correct path", clientName)
 		}
+
+		for _, strategy := range client.LinkStrategies {
+			switch strategy {
+			case domain.LinkStrategyHardlink, domain.LinkStrategyReflink, domain.LinkStrategySymlink, domain.LinkStrategyCopy:
+			default:
+				log.Fatalf("linkStrategies for client %q contains unknown strategy %q, valid strategies are hardlink, reflink, symlink and copy", clientName, strategy)
+			}
+		}
+
+		if _, err := utils.NewDirOptions(client.DirMode, client.User, client.Group, client.Setgid); err != nil {
+			log.Fatalf("invalid folder permissions for client %q: %v", clientName, err)
+		}
 	}
 
+	folderNamers, err := compileFolderNamers(c.Config.FolderNaming, c.Config.Clients)
+	if err != nil {
+		log.Fatalf("invalid folderNaming config: %v", err)
+	}
+	c.folderNamers = folderNamers
+
 	return c
 }
 
+// DataDir returns the directory seasonpackarr keeps its state in, which is the directory of the config file.
+func (c *AppConfig) DataDir() string {
+	if len(c.Config.ConfigPath) != 0 {
+		return c.Config.ConfigPath
+	}
+
+	return filepath.Dir(viper.ConfigFileUsed())
+}
+
 func (c *AppConfig) defaults() {
 	viper.SetDefault("host", "0.0.0.0")
 	viper.SetDefault("port", 42069)
@@ -323,8 +712,44 @@ func (c *AppConfig) defaults() {
 	viper.SetDefault("smartMode", false)
 	viper.SetDefault("smartModeThreshold", 0.75)
 	viper.SetDefault("parseTorrentFile", false)
+	viper.SetDefault("completionThreshold", 0)
+	viper.SetDefault("metadataTimeout", 60)
+	viper.SetDefault("torrentUrl.allowedHosts", []string{})
+	viper.SetDefault("conflictPolicy", domain.ConflictPolicySkip)
+	viper.SetDefault("incompletePolicy", domain.IncompletePolicySkip)
+	viper.SetDefault("incompleteTimeout", 60)
+	viper.SetDefault("cleanup.enabled", false)
+	viper.SetDefault("cleanup.dryRun", false)
+	viper.SetDefault("cleanup.maxAge", 72)
+	viper.SetDefault("cleanup.interval", 60)
+	viper.SetDefault("recheck.enabled", false)
+	viper.SetDefault("recheck.verifiedTag", "seasonpackarr-verified")
+	viper.SetDefault("recheck.incompleteTag", "seasonpackarr-incomplete")
+	viper.SetDefault("recheck.incompleteAction", domain.IncompleteActionNone)
+	viper.SetDefault("recheck.timeout", 30)
+	viper.SetDefault("sync.enabled", false)
+	viper.SetDefault("sync.interval", 10)
+	viper.SetDefault("separateLanguages", false)
+	viper.SetDefault("episodeExtensions", domain.DefaultEpisodeExtensions)
+	viper.SetDefault("folderNaming.template", "")
+	viper.SetDefault("folderNaming.rules", domain.DefaultFolderRules)
+	viper.SetDefault("folderNaming.exemptGroups", domain.DefaultExemptGroups)
+	viper.SetDefault("companionFiles.enabled", false)
+	viper.SetDefault("companionFiles.include", domain.DefaultCompanionFileGlobs)
+	viper.SetDefault("companionFiles.exclude", []string{})
+	viper.SetDefault("verifyPieces.enabled", false)
+	viper.SetDefault("verifyPieces.mode", domain.VerifyPiecesModeSample)
+	viper.SetDefault("verifyPieces.samples", 8)
 	viper.SetDefault("fuzzyMatching.skipRepackCompare", false)
 	viper.SetDefault("fuzzyMatching.simplifyHdrCompare", false)
+	viper.SetDefault("fuzzyMatching.simplifyAudioCompare", false)
+	viper.SetDefault("fuzzyMatching.skipChannelsCompare", false)
+	viper.SetDefault("fuzzyMatching.simplifyCodecCompare", false)
+	viper.SetDefault("fuzzyMatching.languageCompare", domain.LanguageCompareExact)
+	viper.SetDefault("fuzzyMatching.ignoreMissingStreamingService", false)
+	viper.SetDefault("fuzzyMatching.streamingServiceAliases", [][]string{})
+	viper.SetDefault("fuzzyMatching.groupAliases", [][]string{})
+	viper.SetDefault("fuzzyMatching.groupAliasesFile", "")
 	viper.SetDefault("apiToken", "")
 	viper.SetDefault("notifications.notificationLevel", []string{"MATCH", "ERROR"})
 	viper.SetDefault("notifications.discord", "")
@@ -411,6 +836,8 @@ func (c *AppConfig) load(configPath string) {
 }
 
 func (c *AppConfig) DynamicReload(log logger.Logger) {
+	c.watchGroupAliasesFile(log)
+
 	viper.WatchConfig()
 	viper.OnConfigChange(func(e fsnotify.Event) {
 		c.m.Lock()
@@ -432,12 +859,147 @@ func (c *AppConfig) DynamicReload(log logger.Logger) {
 		parseTorrentFile := viper.GetBool("parseTorrentFile")
 		c.Config.ParseTorrentFile = parseTorrentFile
 
+		completionThreshold := viper.GetFloat64("completionThreshold")
+		c.Config.CompletionThreshold = float32(completionThreshold)
+
+		metadataTimeout := viper.GetInt("metadataTimeout")
+		c.Config.MetadataTimeout = metadataTimeout
+
+		torrentURLAllowedHosts := viper.GetStringSlice("torrentUrl.allowedHosts")
+		c.Config.TorrentURL.AllowedHosts = torrentURLAllowedHosts
+
+		conflictPolicy := viper.GetString("conflictPolicy")
+		c.Config.ConflictPolicy = conflictPolicy
+
+		incompletePolicy := viper.GetString("incompletePolicy")
+		c.Config.IncompletePolicy = incompletePolicy
+
+		incompleteTimeout := viper.GetInt("incompleteTimeout")
+		c.Config.IncompleteTimeout = incompleteTimeout
+
+		cleanupEnabled := viper.GetBool("cleanup.enabled")
+		c.Config.Cleanup.Enabled = cleanupEnabled
+
+		cleanupDryRun := viper.GetBool("cleanup.dryRun")
+		c.Config.Cleanup.DryRun = cleanupDryRun
+
+		cleanupMaxAge := viper.GetInt("cleanup.maxAge")
+		c.Config.Cleanup.MaxAge = cleanupMaxAge
+
+		cleanupInterval := viper.GetInt("cleanup.interval")
+		c.Config.Cleanup.Interval = cleanupInterval
+
+		recheckEnabled := viper.GetBool("recheck.enabled")
+		c.Config.Recheck.Enabled = recheckEnabled
+
+		recheckVerifiedTag := viper.GetString("recheck.verifiedTag")
+		c.Config.Recheck.VerifiedTag = recheckVerifiedTag
+
+		recheckIncompleteTag := viper.GetString("recheck.incompleteTag")
+		c.Config.Recheck.IncompleteTag = recheckIncompleteTag
+
+		recheckIncompleteAction := viper.GetString("recheck.incompleteAction")
+		c.Config.Recheck.IncompleteAction = recheckIncompleteAction
+
+		recheckTimeout := viper.GetInt("recheck.timeout")
+		c.Config.Recheck.Timeout = recheckTimeout
+
+		syncEnabled := viper.GetBool("sync.enabled")
+		c.Config.Sync.Enabled = syncEnabled
+
+		syncInterval := viper.GetInt("sync.interval")
+		c.Config.Sync.Interval = syncInterval
+
+		separateLanguages := viper.GetBool("separateLanguages")
+		c.Config.SeparateLanguages = separateLanguages
+
+		episodeExtensions := viper.GetStringSlice("episodeExtensions")
+		c.Config.EpisodeExtensions = episodeExtensions
+
+		folderNaming := domain.FolderNaming{
+			Template:     viper.GetString("folderNaming.template"),
+			Rules:        c.Config.FolderNaming.Rules,
+			ExemptGroups: viper.GetStringSlice("folderNaming.exemptGroups"),
+		}
+
+		var folderRules []domain.FolderRule
+		if err := viper.UnmarshalKey("folderNaming.rules", &folderRules); err != nil {
+			log.Error().Err(err).Msg("error reloading folder naming rules")
+		} else {
+			folderNaming.Rules = folderRules
+		}
+
+		if folderNamers, err := compileFolderNamers(folderNaming, c.Config.Clients); err != nil {
+			log.Error().Err(err).Msg("error reloading folder naming, keeping previous folder naming")
+		} else {
+			c.Config.FolderNaming = folderNaming
+			c.folderNamers = folderNamers
+		}
+
+		companionFilesEnabled := viper.GetBool("companionFiles.enabled")
+		c.Config.CompanionFiles.Enabled = companionFilesEnabled
+
+		companionFilesInclude := viper.GetStringSlice("companionFiles.include")
+		c.Config.CompanionFiles.Include = companionFilesInclude
+
+		companionFilesExclude := viper.GetStringSlice("companionFiles.exclude")
+		c.Config.CompanionFiles.Exclude = companionFilesExclude
+
+		verifyPiecesEnabled := viper.GetBool("verifyPieces.enabled")
+		c.Config.VerifyPieces.Enabled = verifyPiecesEnabled
+
+		verifyPiecesMode := viper.GetString("verifyPieces.mode")
+		c.Config.VerifyPieces.Mode = verifyPiecesMode
+
+		verifyPiecesSamples := viper.GetInt("verifyPieces.samples")
+		c.Config.VerifyPieces.Samples = verifyPiecesSamples
+
 		skipRepackCompare := viper.GetBool("fuzzyMatching.skipRepackCompare")
 		c.Config.FuzzyMatching.SkipRepackCompare = skipRepackCompare
 
 		simplifyHdrCompare := viper.GetBool("fuzzyMatching.simplifyHdrCompare")
 		c.Config.FuzzyMatching.SimplifyHdrCompare = simplifyHdrCompare
 
+		simplifyAudioCompare := viper.GetBool("fuzzyMatching.simplifyAudioCompare")
+		c.Config.FuzzyMatching.SimplifyAudioCompare = simplifyAudioCompare
+
+		skipChannelsCompare := viper.GetBool("fuzzyMatching.skipChannelsCompare")
+		c.Config.FuzzyMatching.SkipChannelsCompare = skipChannelsCompare
+
+		simplifyCodecCompare := viper.GetBool("fuzzyMatching.simplifyCodecCompare")
+		c.Config.FuzzyMatching.SimplifyCodecCompare = simplifyCodecCompare
+
+		languageCompare := viper.GetString("fuzzyMatching.languageCompare")
+		c.Config.FuzzyMatching.LanguageCompare = languageCompare
+
+		ignoreMissingStreamingService := viper.GetBool("fuzzyMatching.ignoreMissingStreamingService")
+		c.Config.FuzzyMatching.IgnoreMissingStreamingService = ignoreMissingStreamingService
+
+		var streamingServiceAliases [][]string
+		if err := viper.UnmarshalKey("fuzzyMatching.streamingServiceAliases", &streamingServiceAliases); err != nil {
+			log.Error().Err(err).Msg("error reloading streaming service aliases")
+		} else {
+			c.Config.FuzzyMatching.StreamingServiceAliases = streamingServiceAliases
+		}
+
+		var groupAliases [][]string
+		if err := viper.UnmarshalKey("fuzzyMatching.groupAliases", &groupAliases); err != nil {
+			log.Error().Err(err).Msg("error reloading group aliases")
+		} else {
+			c.Config.FuzzyMatching.GroupAliases = groupAliases
+		}
+
+		groupAliasesFile := viper.GetString("fuzzyMatching.groupAliasesFile")
+		if groupAliasesFile != c.Config.FuzzyMatching.GroupAliasesFile {
+			c.Config.FuzzyMatching.GroupAliasesFile = groupAliasesFile
+			c.loadGroupAliasesFile(log)
+
+			select {
+			case c.aliasesChanged <- struct{}{}:
+			default:
+			}
+		}
+
 		notificationLevel := viper.GetStringSlice("notifications.notificationLevel")
 		c.Config.Notifications.NotificationLevel = notificationLevel
 
@@ -0,0 +1,59 @@
+// Copyright (c) 2023 - 2024, nuxen and the seasonpackarr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package config
+
+import (
+	"github.com/nuxencs/seasonpackarr/internal/domain"
+	"github.com/nuxencs/seasonpackarr/internal/utils"
+	"github.com/nuxencs/seasonpackarr/pkg/errors"
+)
+
+// compileFolderNamers compiles the folder naming config for every client, the empty client name holds the namer
+// without a client template.
+func compileFolderNamers(naming domain.FolderNaming, clients map[string]*domain.Client) (map[string]*utils.FolderNamer,
+	error,
+) {
+	folderNamers := make(map[string]*utils.FolderNamer, len(clients)+1)
+
+	folderNamer, err := utils.NewFolderNamer(naming, "")
+	if err != nil {
+		return nil, err
+	}
+	folderNamers[""] = folderNamer
+
+	for clientName, client := range clients {
+		folderNamer, err = utils.NewFolderNamer(naming, client.FolderTemplate)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid folderTemplate for client %q", clientName)
+		}
+		folderNamers[clientName] = folderNamer
+	}
+
+	return folderNamers, nil
+}
+
+// FolderNamer returns the folder namer of the client, which was compiled when the config was loaded. An empty
+// client name returns the namer without a client template.
+func (c *AppConfig) FolderNamer(clientName string) (*utils.FolderNamer, error) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	if folderNamer, ok := c.folderNamers[clientName]; ok {
+		return folderNamer, nil
+	}
+
+	// configs that weren't loaded from a file, e.g. in tests, compile the namers on first use
+	folderNamers, err := compileFolderNamers(c.Config.FolderNaming, c.Config.Clients)
+	if err != nil {
+		return nil, err
+	}
+	c.folderNamers = folderNamers
+
+	folderNamer, ok := folderNamers[clientName]
+	if !ok {
+		return nil, domain.StatusClientNotFound.Error()
+	}
+
+	return folderNamer, nil
+}
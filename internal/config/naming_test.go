@@ -0,0 +1,85 @@
+// Copyright (c) 2023 - 2024, nuxen and the seasonpackarr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package config
+
+import (
+	"testing"
+
+	"github.com/nuxencs/seasonpackarr/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_compileFolderNamers(t *testing.T) {
+	tests := []struct {
+		name    string
+		naming  domain.FolderNaming
+		clients map[string]*domain.Client
+		wantErr string
+	}{
+		{
+			name:    "valid",
+			naming:  domain.FolderNaming{Template: "{{.Title}}", Rules: []domain.FolderRule{{Match: `\s+`, Replace: "."}}},
+			clients: map[string]*domain.Client{"default": {FolderTemplate: "{{.Title}}.S{{.Season}}"}},
+		},
+		{
+			name:    "invalid_rule",
+			naming:  domain.FolderNaming{Rules: []domain.FolderRule{{Match: `(`}}},
+			wantErr: "could not compile folder rule",
+		},
+		{
+			name:    "invalid_template",
+			naming:  domain.FolderNaming{Template: "{{.Title"},
+			wantErr: "could not parse folder template",
+		},
+		{
+			name:    "invalid_client_template",
+			clients: map[string]*domain.Client{"default": {FolderTemplate: "{{.Title"}},
+			wantErr: `invalid folderTemplate for client "default"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			folderNamers, err := compileFolderNamers(tt.naming, tt.clients)
+			if len(tt.wantErr) != 0 {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Len(t, folderNamers, len(tt.clients)+1)
+		})
+	}
+}
+
+func Test_AppConfig_FolderNamer(t *testing.T) {
+	c := &AppConfig{
+		Config: &domain.Config{
+			FolderNaming: domain.FolderNaming{Template: "{{.Title}}.S{{printf \"%02d\" .Season}}-{{.Group}}"},
+			Clients:      map[string]*domain.Client{"default": {FolderTemplate: "{{.Title}}-{{.Group}}"}},
+		},
+	}
+
+	folderNamer, err := c.FolderNamer("default")
+	require.NoError(t, err)
+
+	again, err := c.FolderNamer("default")
+	require.NoError(t, err)
+	assert.Same(t, folderNamer, again, "namers are only compiled once")
+
+	name, err := folderNamer.Format("Series Title S01 1080p WEB-DL DDP 5.1 H.264-RlsGrp")
+	require.NoError(t, err)
+	assert.Equal(t, "Series Title-RlsGrp", name)
+
+	global, err := c.FolderNamer("")
+	require.NoError(t, err)
+
+	name, err = global.Format("Series Title S01 1080p WEB-DL DDP 5.1 H.264-RlsGrp")
+	require.NoError(t, err)
+	assert.Equal(t, "Series Title.S01-RlsGrp", name)
+
+	_, err = c.FolderNamer("missing")
+	assert.EqualError(t, err, domain.StatusClientNotFound.String())
+}
@@ -4,17 +4,160 @@
 
 package domain
 
+var DefaultEpisodeExtensions = []string{"mkv"}
+
+var DefaultCompanionFileGlobs = []string{"*.srt", "*.ass", "*.ssa", "*.sub", "*.idx", "*.nfo"}
+
+// DefaultFolderRules remove characters that are illegal in folder names, replace spaces with periods, fix the
+// audio naming, e.g. DDP.5.1 to DDP5.1, and collapse multiple periods into one.
+var DefaultFolderRules = []FolderRule{
+	{Match: `[\\/:"*?<>|]`, Replace: ""},
+	{Match: ` `, Replace: "."},
+	{Match: `(?i)(AAC|DDP)\.(\d\.\d)`, Replace: "$1$2"},
+	{Match: `\.+`, Replace: "."},
+}
+
+// DefaultExemptGroups are release groups whose folder name doesn't need to be adjusted.
+var DefaultExemptGroups = []string{"ZR"}
+
+const (
+	LinkStrategyHardlink = "hardlink"
+	LinkStrategyReflink  = "reflink"
+	LinkStrategySymlink  = "symlink"
+	LinkStrategyCopy     = "copy"
+)
+
+var DefaultLinkStrategies = []string{LinkStrategyHardlink}
+
+const (
+	ConflictPolicySkip      = "skip"
+	ConflictPolicyOverwrite = "overwrite"
+)
+
+const (
+	IncompletePolicySkip = "skip"
+	IncompletePolicyWait = "wait"
+)
+
+// PathMapping translates a path as the client sees it to the same path as seasonpackarr sees it, e.g. if both run in
+// containers with different mounts.
+type PathMapping struct {
+	Remote string `yaml:"remote"`
+	Local  string `yaml:"local"`
+}
+
+// DefaultRecheckTimeout is the number of minutes to wait for the recheck of an injected season pack.
+const DefaultRecheckTimeout = 30
+
+type Inject struct {
+	Enabled        bool     `yaml:"enabled"`
+	Category       string   `yaml:"category"`
+	Tags           []string `yaml:"tags"`
+	RecheckTimeout int      `yaml:"recheckTimeout"`
+}
+
+// TorrentFilters limit the torrents of a client that are matched against season packs.
+type TorrentFilters struct {
+	Categories       []string `yaml:"categories"`
+	Tags             []string `yaml:"tags"`
+	SavePathPrefixes []string `yaml:"savePathPrefixes"`
+	CompletedOnly    bool     `yaml:"completedOnly"`
+}
+
 type Client struct {
-	Host          string `yaml:"host"`
-	Port          int    `yaml:"port"`
-	Username      string `yaml:"username"`
-	Password      string `yaml:"password"`
-	PreImportPath string `yaml:"preImportPath"`
+	Host              string         `yaml:"host"`
+	Port              int            `yaml:"port"`
+	Username          string         `yaml:"username"`
+	Password          string         `yaml:"password"`
+	PreImportPath     string         `yaml:"preImportPath"`
+	EpisodeExtensions []string       `yaml:"episodeExtensions"`
+	FolderTemplate    string         `yaml:"folderTemplate"`
+	LinkStrategies    []string       `yaml:"linkStrategies"`
+	PathMappings      []PathMapping  `yaml:"pathMappings"`
+	DirMode           string         `yaml:"dirMode"`
+	User              string         `yaml:"user"`
+	Group             string         `yaml:"group"`
+	Setgid            bool           `yaml:"setgid"`
+	Inject            Inject         `yaml:"inject"`
+	Filters           TorrentFilters `yaml:"filters"`
 }
 
+const (
+	LanguageCompareExact  = "exact"
+	LanguageCompareSubset = "subset"
+	LanguageCompareIgnore = "ignore"
+)
+
 type FuzzyMatching struct {
-	SkipRepackCompare  bool `yaml:"skipRepackCompare"`
-	SimplifyHdrCompare bool `yaml:"simplifyHdrCompare"`
+	SkipRepackCompare             bool       `yaml:"skipRepackCompare"`
+	SimplifyHdrCompare            bool       `yaml:"simplifyHdrCompare"`
+	SimplifyAudioCompare          bool       `yaml:"simplifyAudioCompare"`
+	SkipChannelsCompare           bool       `yaml:"skipChannelsCompare"`
+	SimplifyCodecCompare          bool       `yaml:"simplifyCodecCompare"`
+	LanguageCompare               string     `yaml:"languageCompare"`
+	IgnoreMissingStreamingService bool       `yaml:"ignoreMissingStreamingService"`
+	StreamingServiceAliases       [][]string `yaml:"streamingServiceAliases"`
+	GroupAliases                  [][]string `yaml:"groupAliases"`
+	GroupAliasesFile              string     `yaml:"groupAliasesFile"`
+}
+
+type CompanionFiles struct {
+	Enabled bool     `yaml:"enabled"`
+	Include []string `yaml:"include"`
+	Exclude []string `yaml:"exclude"`
+}
+
+type FolderRule struct {
+	Match   string `yaml:"match"`
+	Replace string `yaml:"replace"`
+}
+
+type FolderNaming struct {
+	Template     string       `yaml:"template"`
+	Rules        []FolderRule `yaml:"rules"`
+	ExemptGroups []string     `yaml:"exemptGroups"`
+}
+
+const (
+	VerifyPiecesModeFull   = "full"
+	VerifyPiecesModeSample = "sample"
+)
+
+type VerifyPieces struct {
+	Enabled bool   `yaml:"enabled"`
+	Mode    string `yaml:"mode"`
+	Samples int    `yaml:"samples"`
+}
+
+type Cleanup struct {
+	Enabled  bool `yaml:"enabled"`
+	DryRun   bool `yaml:"dryRun"`
+	MaxAge   int  `yaml:"maxAge"`
+	Interval int  `yaml:"interval"`
+}
+
+const (
+	IncompleteActionNone   = "none"
+	IncompleteActionPause  = "pause"
+	IncompleteActionResume = "resume"
+)
+
+type Recheck struct {
+	Enabled          bool   `yaml:"enabled"`
+	VerifiedTag      string `yaml:"verifiedTag"`
+	IncompleteTag    string `yaml:"incompleteTag"`
+	IncompleteAction string `yaml:"incompleteAction"`
+	Timeout          int    `yaml:"timeout"`
+}
+
+type Sync struct {
+	Enabled  bool `yaml:"enabled"`
+	Interval int  `yaml:"interval"`
+}
+
+// TorrentURL limits where torrent files passed as url to the parse endpoint are downloaded from.
+type TorrentURL struct {
+	AllowedHosts []string `yaml:"allowedHosts"`
 }
 
 type Notifications struct {
@@ -25,19 +168,33 @@ type Notifications struct {
 }
 
 type Config struct {
-	Version            string
-	ConfigPath         string
-	Host               string             `yaml:"host"`
-	Port               int                `yaml:"port"`
-	Clients            map[string]*Client `yaml:"clients"`
-	LogPath            string             `yaml:"logPath"`
-	LogLevel           string             `yaml:"logLevel"`
-	LogMaxSize         int                `yaml:"logMaxSize"`
-	LogMaxBackups      int                `yaml:"logMaxBackups"`
-	SmartMode          bool               `yaml:"smartMode"`
-	SmartModeThreshold float32            `yaml:"smartModeThreshold"`
-	ParseTorrentFile   bool               `yaml:"parseTorrentFile"`
-	FuzzyMatching      FuzzyMatching      `yaml:"fuzzyMatching"`
-	APIToken           string             `yaml:"apiToken"`
-	Notifications      Notifications      `yaml:"notifications"`
+	Version             string
+	ConfigPath          string
+	Host                string             `yaml:"host"`
+	Port                int                `yaml:"port"`
+	Clients             map[string]*Client `yaml:"clients"`
+	LogPath             string             `yaml:"logPath"`
+	LogLevel            string             `yaml:"logLevel"`
+	LogMaxSize          int                `yaml:"logMaxSize"`
+	LogMaxBackups       int                `yaml:"logMaxBackups"`
+	SmartMode           bool               `yaml:"smartMode"`
+	SmartModeThreshold  float32            `yaml:"smartModeThreshold"`
+	ParseTorrentFile    bool               `yaml:"parseTorrentFile"`
+	SeparateLanguages   bool               `yaml:"separateLanguages"`
+	EpisodeExtensions   []string           `yaml:"episodeExtensions"`
+	FuzzyMatching       FuzzyMatching      `yaml:"fuzzyMatching"`
+	FolderNaming        FolderNaming       `yaml:"folderNaming"`
+	CompanionFiles      CompanionFiles     `yaml:"companionFiles"`
+	VerifyPieces        VerifyPieces       `yaml:"verifyPieces"`
+	CompletionThreshold float32            `yaml:"completionThreshold"`
+	MetadataTimeout     int                `yaml:"metadataTimeout"`
+	TorrentURL          TorrentURL         `yaml:"torrentUrl"`
+	ConflictPolicy      string             `yaml:"conflictPolicy"`
+	IncompletePolicy    string             `yaml:"incompletePolicy"`
+	IncompleteTimeout   int                `yaml:"incompleteTimeout"`
+	Cleanup             Cleanup            `yaml:"cleanup"`
+	Recheck             Recheck            `yaml:"recheck"`
+	Sync                Sync               `yaml:"sync"`
+	APIToken            string             `yaml:"apiToken"`
+	Notifications       Notifications      `yaml:"notifications"`
 }
@@ -22,10 +22,21 @@ const (
 	StatusSizeMismatch             StatusCode = 212
 	StatusSeasonMismatch           StatusCode = 213
 	StatusEpisodeMismatch          StatusCode = 214
+	StatusAudioMismatch            StatusCode = 215
+	StatusChannelsMismatch         StatusCode = 216
+	StatusCodecMismatch            StatusCode = 217
+	StatusLanguageMismatch         StatusCode = 218
+	StatusCompanionMismatch        StatusCode = 219
+	StatusPieceMismatch            StatusCode = 220
+	StatusIncompleteTorrent        StatusCode = 221
 	StatusBelowThreshold           StatusCode = 230
+	StatusBelowCompletion          StatusCode = 231
+	StatusRecheckIncomplete        StatusCode = 232
 	StatusSuccessfulMatch          StatusCode = 250
 	StatusSuccessfulHardlink       StatusCode = 250
+	StatusRecheckVerified          StatusCode = 251
 	StatusFailedHardlink           StatusCode = 440
+	StatusLinkConflict             StatusCode = 441
 	StatusFailedMatchToTorrentEps  StatusCode = 445
 	StatusClientNotFound           StatusCode = 472
 	StatusGetClientError           StatusCode = 471
@@ -36,6 +47,12 @@ const (
 	StatusDecodeTorrentBytesError  StatusCode = 466
 	StatusParseTorrentInfoError    StatusCode = 465
 	StatusGetEpisodesError         StatusCode = 464
+	StatusFetchTorrentError        StatusCode = 463
+	StatusMetadataError            StatusCode = 462
+	StatusFolderNameError          StatusCode = 461
+	StatusUnsafePath               StatusCode = 460
+	StatusInjectError              StatusCode = 459
+	StatusRecheckError             StatusCode = 458
 	StatusEpisodeCountError        StatusCode = 450
 )
 
@@ -69,12 +86,34 @@ func (s StatusCode) String() string {
 		return "season did not match"
 	case StatusEpisodeMismatch:
 		return "episode did not match"
+	case StatusAudioMismatch:
+		return "audio did not match"
+	case StatusChannelsMismatch:
+		return "audio channels did not match"
+	case StatusCodecMismatch:
+		return "video codec did not match"
+	case StatusLanguageMismatch:
+		return "language did not match"
+	case StatusCompanionMismatch:
+		return "companion file did not match"
+	case StatusPieceMismatch:
+		return "pieces did not verify"
+	case StatusIncompleteTorrent:
+		return "matching torrents in client are incomplete"
 	case StatusBelowThreshold:
 		return "number of matches below threshold"
+	case StatusBelowCompletion:
+		return "completion after recheck below threshold"
+	case StatusRecheckIncomplete:
+		return "season pack incomplete after recheck"
 	case StatusSuccessfulMatch:
 		return "successful match"
+	case StatusRecheckVerified:
+		return "season pack verified after recheck"
 	case StatusFailedHardlink:
 		return "could not create hardlinks"
+	case StatusLinkConflict:
+		return "conflicting files in season pack folder"
 	case StatusFailedMatchToTorrentEps:
 		return "could not match episodes to files in pack"
 	case StatusClientNotFound:
@@ -95,6 +134,18 @@ func (s StatusCode) String() string {
 		return "could not parse torrent info"
 	case StatusGetEpisodesError:
 		return "could not get episodes"
+	case StatusFetchTorrentError:
+		return "could not fetch torrent"
+	case StatusMetadataError:
+		return "could not get torrent metadata"
+	case StatusFolderNameError:
+		return "could not format folder name"
+	case StatusUnsafePath:
+		return "unsafe path in season pack"
+	case StatusInjectError:
+		return "could not add season pack to client"
+	case StatusRecheckError:
+		return "could not verify season pack after recheck"
 	case StatusEpisodeCountError:
 		return "could not get episode count"
 	default:
@@ -113,6 +164,7 @@ func (s StatusCode) Error() error {
 var NotificationStatusMap = map[string][]StatusCode{
 	NotificationLevelMatch: {
 		StatusSuccessfulMatch,
+		StatusRecheckVerified,
 	},
 	NotificationLevelInfo: {
 		StatusNoMatches,
@@ -124,12 +176,20 @@ var NotificationStatusMap = map[string][]StatusCode{
 		StatusRepackStatusMismatch,
 		StatusHdrMismatch,
 		StatusStreamingServiceMismatch,
+		StatusAudioMismatch,
+		StatusChannelsMismatch,
+		StatusCodecMismatch,
+		StatusLanguageMismatch,
 		StatusAlreadyInClient,
 		StatusNotASeasonPack,
+		StatusIncompleteTorrent,
 		StatusBelowThreshold,
+		StatusBelowCompletion,
+		StatusRecheckIncomplete,
 	},
 	NotificationLevelError: {
 		StatusFailedHardlink,
+		StatusLinkConflict,
 		StatusFailedMatchToTorrentEps,
 		StatusClientNotFound,
 		StatusGetClientError,
@@ -140,6 +200,12 @@ var NotificationStatusMap = map[string][]StatusCode{
 		StatusDecodeTorrentBytesError,
 		StatusParseTorrentInfoError,
 		StatusGetEpisodesError,
+		StatusFetchTorrentError,
+		StatusMetadataError,
+		StatusFolderNameError,
+		StatusUnsafePath,
+		StatusInjectError,
+		StatusRecheckError,
 		StatusEpisodeCountError,
 	},
 }
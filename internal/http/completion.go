@@ -0,0 +1,54 @@
+// Copyright (c) 2023 - 2024, nuxen and the seasonpackarr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package http
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/autobrr/go-qbittorrent"
+)
+
+// completionPollInterval is how often the client is asked whether an incomplete torrent finished downloading.
+const completionPollInterval = 5 * time.Second
+
+// isTorrentComplete reports whether the torrent finished downloading and its files can be linked.
+func isTorrentComplete(t qbittorrent.Torrent) bool {
+	switch t.State {
+	case qbittorrent.TorrentStateError, qbittorrent.TorrentStateMissingFiles:
+		return false
+	}
+
+	return t.Progress >= 1 && !isChecking(t.State)
+}
+
+// waitForCompletion polls the client until the torrent finished downloading or the context is done.
+func waitForCompletion(ctx context.Context, client *qbittorrent.Client, hash string) (qbittorrent.Torrent, error) {
+	ticker := time.NewTicker(completionPollInterval)
+	defer ticker.Stop()
+
+	for {
+		found, err := client.GetTorrentsCtx(ctx, qbittorrent.TorrentFilterOptions{Hashes: []string{hash}})
+		if err != nil && ctx.Err() == nil {
+			return qbittorrent.Torrent{}, err
+		}
+
+		if err == nil {
+			if len(found) == 0 {
+				return qbittorrent.Torrent{}, fmt.Errorf("torrent was removed from client")
+			}
+
+			if isTorrentComplete(found[0]) {
+				return found[0], nil
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return qbittorrent.Torrent{}, fmt.Errorf("torrent didn't finish downloading in time")
+		case <-ticker.C:
+		}
+	}
+}
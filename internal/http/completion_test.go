@@ -0,0 +1,94 @@
+// Copyright (c) 2023 - 2024, nuxen and the seasonpackarr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package http
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/autobrr/go-qbittorrent"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_isTorrentComplete(t *testing.T) {
+	tests := []struct {
+		name    string
+		torrent qbittorrent.Torrent
+		want    bool
+	}{
+		{
+			name:    "seeding",
+			torrent: qbittorrent.Torrent{State: qbittorrent.TorrentStateStalledUp, Progress: 1},
+			want:    true,
+		},
+		{
+			name:    "stopped",
+			torrent: qbittorrent.Torrent{State: qbittorrent.TorrentStateStoppedUp, Progress: 1},
+			want:    true,
+		},
+		{
+			name:    "downloading",
+			torrent: qbittorrent.Torrent{State: qbittorrent.TorrentStateDownloading, Progress: 0.5},
+		},
+		{
+			name:    "checking",
+			torrent: qbittorrent.Torrent{State: qbittorrent.TorrentStateCheckingUp, Progress: 1},
+		},
+		{
+			name:    "missing_files",
+			torrent: qbittorrent.Torrent{State: qbittorrent.TorrentStateMissingFiles, Progress: 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, isTorrentComplete(tt.torrent))
+		})
+	}
+}
+
+func Test_waitForCompletion(t *testing.T) {
+	const hash = "0123456789abcdef0123456789abcdef01234567"
+
+	tests := []struct {
+		name     string
+		torrents []qbittorrent.Torrent
+		timeout  time.Duration
+		wantErr  bool
+	}{
+		{
+			name:     "complete",
+			torrents: []qbittorrent.Torrent{{Hash: hash, State: qbittorrent.TorrentStateUploading, Progress: 1}},
+			timeout:  time.Second,
+		},
+		{
+			name:     "incomplete",
+			torrents: []qbittorrent.Torrent{{Hash: hash, State: qbittorrent.TorrentStateDownloading, Progress: 0.5}},
+			timeout:  100 * time.Millisecond,
+			wantErr:  true,
+		},
+		{
+			name:    "removed",
+			timeout: time.Second,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, client := newFakeClient(t, tt.torrents)
+
+			ctx, cancel := context.WithTimeout(context.Background(), tt.timeout)
+			defer cancel()
+
+			got, err := waitForCompletion(ctx, client, hash)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, hash, got.Hash)
+		})
+	}
+}
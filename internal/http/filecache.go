@@ -0,0 +1,87 @@
+// Copyright (c) 2023 - 2024, nuxen and the seasonpackarr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package http
+
+import (
+	"sync/atomic"
+
+	"github.com/autobrr/go-qbittorrent"
+	"github.com/puzpuzpuz/xsync/v3"
+)
+
+// cachedFiles is the file list of a torrent along with the state and size the torrent had when it was fetched.
+type cachedFiles struct {
+	state qbittorrent.TorrentState
+	size  int64
+	files *qbittorrent.TorrentFiles
+}
+
+// fileCache caches the file lists of the torrents of a client by infohash. A cached list is only used as long as the
+// state and size of the torrent didn't change, only complete torrents are looked up, so their file progress is final.
+type fileCache struct {
+	files  *xsync.MapOf[string, cachedFiles]
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+// fileCacheStats are the metrics of the file list cache of a client.
+type fileCacheStats struct {
+	Entries int   `json:"entries"`
+	Hits    int64 `json:"hits"`
+	Misses  int64 `json:"misses"`
+}
+
+var fileCaches = xsync.NewMapOf[string, *fileCache]()
+
+// getFileCache returns the file list cache of the client with the given name, creating it on first use.
+func getFileCache(clientName string) *fileCache {
+	c, _ := fileCaches.LoadOrCompute(clientName, func() *fileCache {
+		return &fileCache{files: xsync.NewMapOf[string, cachedFiles]()}
+	})
+
+	return c
+}
+
+// get returns the cached file list of the torrent, fetching it if it isn't cached or the torrent changed since.
+func (c *fileCache) get(t qbittorrent.Torrent, fetch func(hash string) (*qbittorrent.TorrentFiles, error)) (
+	*qbittorrent.TorrentFiles, error,
+) {
+	if cached, ok := c.files.Load(t.Hash); ok && cached.state == t.State && cached.size == t.Size {
+		c.hits.Add(1)
+		return cached.files, nil
+	}
+	c.misses.Add(1)
+
+	files, err := fetch(t.Hash)
+	if err != nil {
+		return nil, err
+	}
+
+	c.files.Store(t.Hash, cachedFiles{state: t.State, size: t.Size, files: files})
+
+	return files, nil
+}
+
+// retain removes the file lists of all torrents that aren't in the client anymore.
+func (c *fileCache) retain(ts []qbittorrent.Torrent) {
+	hashes := make(map[string]struct{}, len(ts))
+	for _, t := range ts {
+		hashes[t.Hash] = struct{}{}
+	}
+
+	c.files.Range(func(hash string, _ cachedFiles) bool {
+		if _, ok := hashes[hash]; !ok {
+			c.files.Delete(hash)
+		}
+		return true
+	})
+}
+
+func (c *fileCache) stats() fileCacheStats {
+	return fileCacheStats{
+		Entries: c.files.Size(),
+		Hits:    c.hits.Load(),
+		Misses:  c.misses.Load(),
+	}
+}
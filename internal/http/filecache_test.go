@@ -0,0 +1,65 @@
+// Copyright (c) 2023 - 2024, nuxen and the seasonpackarr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package http
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/autobrr/go-qbittorrent"
+	"github.com/puzpuzpuz/xsync/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_fileCache_get(t *testing.T) {
+	cache := &fileCache{files: xsync.NewMapOf[string, cachedFiles]()}
+
+	var fetches int
+	fetch := func(hash string) (*qbittorrent.TorrentFiles, error) {
+		fetches++
+		if hash == "broken" {
+			return nil, fmt.Errorf("could not get files")
+		}
+		return &qbittorrent.TorrentFiles{{Name: fmt.Sprintf("%s-%d.mkv", hash, fetches)}}, nil
+	}
+
+	seeding := qbittorrent.Torrent{Hash: "a", State: qbittorrent.TorrentStateStalledUp, Size: 100}
+	resized := qbittorrent.Torrent{Hash: "a", State: qbittorrent.TorrentStateStalledUp, Size: 200}
+	stopped := qbittorrent.Torrent{Hash: "a", State: qbittorrent.TorrentStateStoppedUp, Size: 200}
+
+	tests := []struct {
+		name       string
+		torrent    qbittorrent.Torrent
+		wantFile   string
+		wantErr    bool
+		wantHits   int64
+		wantMisses int64
+	}{
+		{name: "miss", torrent: seeding, wantFile: "a-1.mkv", wantMisses: 1},
+		{name: "hit", torrent: seeding, wantFile: "a-1.mkv", wantHits: 1, wantMisses: 1},
+		{name: "size_changed", torrent: resized, wantFile: "a-2.mkv", wantHits: 1, wantMisses: 2},
+		{name: "state_changed", torrent: stopped, wantFile: "a-3.mkv", wantHits: 1, wantMisses: 3},
+		{name: "hit_after_change", torrent: stopped, wantFile: "a-3.mkv", wantHits: 2, wantMisses: 3},
+		{name: "error", torrent: qbittorrent.Torrent{Hash: "broken"}, wantErr: true, wantHits: 2, wantMisses: 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := cache.get(tt.torrent, fetch)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.wantFile, (*got)[0].Name)
+			}
+
+			stats := cache.stats()
+			assert.Equal(t, tt.wantHits, stats.Hits)
+			assert.Equal(t, tt.wantMisses, stats.Misses)
+		})
+	}
+
+	cache.retain([]qbittorrent.Torrent{{Hash: "b"}})
+	assert.Equal(t, 0, cache.stats().Entries)
+}
@@ -0,0 +1,108 @@
+// Copyright (c) 2023 - 2024, nuxen and the seasonpackarr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package http
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/nuxencs/seasonpackarr/internal/domain"
+	"github.com/nuxencs/seasonpackarr/internal/utils"
+
+	"github.com/autobrr/go-qbittorrent"
+)
+
+// getFilteredTorrents returns the torrents of the client that pass the filters. The client only supports a single
+// category and tag per request, so the torrents of every category, or of every tag if no categories are set, are
+// requested separately. All other filters are applied afterward.
+func getFilteredTorrents(ctx context.Context, client *qbittorrent.Client, filters domain.TorrentFilters) (
+	[]qbittorrent.Torrent, error,
+) {
+	opts := qbittorrent.TorrentFilterOptions{}
+	if filters.CompletedOnly {
+		opts.Filter = qbittorrent.TorrentFilterCompleted
+	}
+
+	var requests []qbittorrent.TorrentFilterOptions
+
+	// an empty category or tag selects torrents without one, which can't be requested from the client
+	switch {
+	case len(filters.Categories) != 0 && !slices.Contains(filters.Categories, ""):
+		for _, category := range filters.Categories {
+			categoryOpts := opts
+			categoryOpts.Category = category
+			requests = append(requests, categoryOpts)
+		}
+	case len(filters.Tags) != 0 && !slices.Contains(filters.Tags, ""):
+		for _, tag := range filters.Tags {
+			tagOpts := opts
+			tagOpts.Tag = tag
+			requests = append(requests, tagOpts)
+		}
+	default:
+		requests = append(requests, opts)
+	}
+
+	var filtered []qbittorrent.Torrent
+	seen := make(map[string]struct{})
+
+	for _, requestOpts := range requests {
+		ts, err := client.GetTorrentsCtx(ctx, requestOpts)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, t := range ts {
+			if _, ok := seen[t.Hash]; ok || !matchesFilters(t, filters) {
+				continue
+			}
+
+			seen[t.Hash] = struct{}{}
+			filtered = append(filtered, t)
+		}
+	}
+
+	return filtered, nil
+}
+
+// hasFilters reports whether any filter is set, so not every torrent of the client is a link candidate.
+func hasFilters(filters domain.TorrentFilters) bool {
+	return len(filters.Categories) != 0 || len(filters.Tags) != 0 || len(filters.SavePathPrefixes) != 0 ||
+		filters.CompletedOnly
+}
+
+// matchesFilters reports whether the torrent passes the filters and may be linked. A torrent needs to match one of
+// the categories, one of the tags and one of the save path prefixes, unset filters match every torrent.
+func matchesFilters(t qbittorrent.Torrent, filters domain.TorrentFilters) bool {
+	if len(filters.Categories) != 0 && !slices.Contains(filters.Categories, t.Category) {
+		return false
+	}
+
+	if len(filters.Tags) != 0 {
+		var torrentTags []string
+		for _, tag := range strings.Split(t.Tags, ",") {
+			if tag = strings.TrimSpace(tag); len(tag) != 0 {
+				torrentTags = append(torrentTags, tag)
+			}
+		}
+
+		if !slices.ContainsFunc(filters.Tags, func(tag string) bool {
+			if len(tag) == 0 {
+				return len(torrentTags) == 0
+			}
+			return slices.Contains(torrentTags, tag)
+		}) {
+			return false
+		}
+	}
+
+	if len(filters.SavePathPrefixes) != 0 && !slices.ContainsFunc(filters.SavePathPrefixes, func(prefix string) bool {
+		return utils.HasRemotePathPrefix(t.SavePath, prefix)
+	}) {
+		return false
+	}
+
+	return !filters.CompletedOnly || t.Progress >= 1
+}
@@ -0,0 +1,132 @@
+// Copyright (c) 2023 - 2024, nuxen and the seasonpackarr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/nuxencs/seasonpackarr/internal/domain"
+
+	"github.com/autobrr/go-qbittorrent"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newFilteringClient returns a client for a fake qBittorrent that applies the category, tag and filter parameters
+// of torrent list requests like the real one, and counts the requests it received.
+func newFilteringClient(t *testing.T, torrents []qbittorrent.Torrent) (*qbittorrent.Client, *atomic.Int32) {
+	var calls atomic.Int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v2/torrents/info" {
+			http.NotFound(w, r)
+			return
+		}
+		calls.Add(1)
+
+		query := r.URL.Query()
+		var found []qbittorrent.Torrent
+
+		for _, torrent := range torrents {
+			if query.Has("category") && torrent.Category != query.Get("category") ||
+				query.Has("tag") && !slices.Contains(strings.Split(torrent.Tags, ", "), query.Get("tag")) ||
+				query.Get("filter") == string(qbittorrent.TorrentFilterCompleted) && torrent.Progress < 1 {
+				continue
+			}
+			found = append(found, torrent)
+		}
+
+		_ = json.NewEncoder(w).Encode(found)
+	}))
+	t.Cleanup(srv.Close)
+
+	return qbittorrent.NewClient(qbittorrent.Config{Host: srv.URL}), &calls
+}
+
+func Test_getFilteredTorrents(t *testing.T) {
+	torrents := []qbittorrent.Torrent{
+		{Hash: "a", Category: "tv", Tags: "seasonpackarr, tracker", SavePath: "/downloads/tv", Progress: 1},
+		{Hash: "b", Category: "tv", SavePath: "/downloads/tv-archive", Progress: 0.5},
+		{Hash: "c", Category: "movies", Tags: "tracker", SavePath: "/downloads/movies", Progress: 1},
+		{Hash: "d", Tags: "seasonpackarr", SavePath: `D:\Downloads\TV\Show`, Progress: 1},
+	}
+
+	tests := []struct {
+		name      string
+		filters   domain.TorrentFilters
+		wantFound []string
+		wantCalls int32
+	}{
+		{
+			name:      "no_filters",
+			wantFound: []string{"a", "b", "c", "d"},
+			wantCalls: 1,
+		},
+		{
+			name:      "categories",
+			filters:   domain.TorrentFilters{Categories: []string{"tv", "movies"}},
+			wantFound: []string{"a", "b", "c"},
+			wantCalls: 2,
+		},
+		{
+			name:      "uncategorized",
+			filters:   domain.TorrentFilters{Categories: []string{"tv", ""}},
+			wantFound: []string{"a", "b", "d"},
+			wantCalls: 1,
+		},
+		{
+			name:      "categories_and_tags",
+			filters:   domain.TorrentFilters{Categories: []string{"tv", "movies"}, Tags: []string{"tracker"}},
+			wantFound: []string{"a", "c"},
+			wantCalls: 2,
+		},
+		{
+			name:      "tags",
+			filters:   domain.TorrentFilters{Tags: []string{"seasonpackarr", "tracker"}},
+			wantFound: []string{"a", "d", "c"},
+			wantCalls: 2,
+		},
+		{
+			name:      "untagged",
+			filters:   domain.TorrentFilters{Tags: []string{""}},
+			wantFound: []string{"b"},
+			wantCalls: 1,
+		},
+		{
+			name:      "save_path_prefixes",
+			filters:   domain.TorrentFilters{SavePathPrefixes: []string{"/downloads/tv/", "D:/Downloads/TV"}},
+			wantFound: []string{"a", "d"},
+			wantCalls: 1,
+		},
+		{
+			name:      "completed_only",
+			filters:   domain.TorrentFilters{Categories: []string{"tv"}, CompletedOnly: true},
+			wantFound: []string{"a"},
+			wantCalls: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, calls := newFilteringClient(t, torrents)
+
+			got, err := getFilteredTorrents(context.Background(), client, tt.filters)
+			require.NoError(t, err)
+
+			var found []string
+			for _, torrent := range got {
+				found = append(found, torrent.Hash)
+			}
+
+			assert.Equal(t, tt.wantFound, found)
+			assert.Equal(t, tt.wantCalls, calls.Load())
+		})
+	}
+}
@@ -6,14 +6,24 @@ package http
 
 import (
 	"net/http"
+	"os"
+
+	"github.com/nuxencs/seasonpackarr/internal/config"
+	"github.com/nuxencs/seasonpackarr/internal/logger"
 
 	"github.com/gin-gonic/gin"
 )
 
-type healthHandler struct{}
+type healthHandler struct {
+	log logger.Logger
+	cfg *config.AppConfig
+}
 
-func newHealthHandler() *healthHandler {
-	return &healthHandler{}
+func newHealthHandler(log logger.Logger, cfg *config.AppConfig) *healthHandler {
+	return &healthHandler{
+		log: log,
+		cfg: cfg,
+	}
 }
 
 func (h *healthHandler) Routes(r *gin.RouterGroup) {
@@ -26,9 +36,31 @@ func (h *healthHandler) handleLiveness(c *gin.Context) {
 }
 
 func (h *healthHandler) handleReadiness(c *gin.Context) {
+	if !h.pathMappingsResolve() {
+		writeUnhealthy(c)
+		return
+	}
+
 	writeHealthy(c)
 }
 
+// pathMappingsResolve reports whether the local path of every path mapping is a directory seasonpackarr can access.
+func (h *healthHandler) pathMappingsResolve() bool {
+	ok := true
+
+	for clientName, client := range h.cfg.Config.Clients {
+		for _, mapping := range client.PathMappings {
+			if fi, err := os.Stat(mapping.Local); err != nil || !fi.IsDir() {
+				h.log.Error().Err(err).Msgf("path mapping %q of client %q doesn't resolve to a local directory: %s",
+					mapping.Remote, clientName, mapping.Local)
+				ok = false
+			}
+		}
+	}
+
+	return ok
+}
+
 func writeHealthy(c *gin.Context) {
 	c.Header("Content-Type", "text/plain")
 	c.String(http.StatusOK, "OK")
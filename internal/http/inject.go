@@ -0,0 +1,242 @@
+// Copyright (c) 2023 - 2024, nuxen and the seasonpackarr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package http
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nuxencs/seasonpackarr/internal/domain"
+	"github.com/nuxencs/seasonpackarr/internal/utils"
+	"github.com/nuxencs/seasonpackarr/pkg/errors"
+
+	"github.com/autobrr/go-qbittorrent"
+)
+
+const (
+	// recheckPollInterval is how often the client is asked whether the recheck of an injected season pack finished.
+	recheckPollInterval = 2 * time.Second
+	// recheckStartTimeout is how long to wait for the client to start checking, small packs might finish checking
+	// before they are polled for the first time.
+	recheckStartTimeout = 10 * time.Second
+	// recheckResponseTimeout is how long the parse request waits for the recheck of an injected season pack, packs
+	// that take longer are watched in the background and reported as pending.
+	recheckResponseTimeout = 15 * time.Second
+)
+
+const (
+	recheckStateVerified   = "verified"
+	recheckStateIncomplete = "incomplete"
+	recheckStatePending    = "pending"
+	recheckStateError      = "error"
+)
+
+// recheckReport is the state of the recheck of an injected season pack as returned by the parse endpoint.
+type recheckReport struct {
+	State    string  `json:"state"`
+	Progress float64 `json:"progress"`
+}
+
+// isChecking reports whether the client is still busy with the torrent before its progress is final.
+func isChecking(state qbittorrent.TorrentState) bool {
+	switch state {
+	case qbittorrent.TorrentStateCheckingUp, qbittorrent.TorrentStateCheckingDl,
+		qbittorrent.TorrentStateCheckingResumeData, qbittorrent.TorrentStateAllocating,
+		qbittorrent.TorrentStateMetaDl, qbittorrent.TorrentStateMoving:
+		return true
+	default:
+		return false
+	}
+}
+
+// injectSeasonPack adds the season pack to the client with the pre import path as save path, so the client finds
+// the linked episodes, and triggers a recheck. The pack is added stopped, so nothing is downloaded before the recheck
+// finished. The request waits a little for the result of the recheck, longer rechecks are watched in the background.
+// Either way the result is handled like the rechecks of other prepared packs if recheck is enabled, see
+// handleRecheckResult.
+func (p *processor) injectSeasonPack(ctx context.Context, clientCfg *domain.Client, clientName string,
+	pack seasonPack,
+) error {
+	if len(pack.hash) == 0 {
+		return fmt.Errorf("unknown infohash")
+	}
+
+	if err := p.getClient(clientCfg, clientName); err != nil {
+		return err
+	}
+
+	existing, err := p.req.Client.GetTorrentsCtx(ctx, qbittorrent.TorrentFilterOptions{Hashes: []string{pack.hash}})
+	if err != nil {
+		return err
+	}
+
+	if len(existing) != 0 {
+		p.log.Info().Msgf("season pack is already in client, skipping injection: %s", pack.hash)
+		return nil
+	}
+
+	options := map[string]string{
+		"savepath":      utils.MapLocalPath(clientCfg.PreImportPath, clientCfg.PathMappings),
+		"autoTMM":       "false",
+		"contentLayout": "Original",
+		"skip_checking": "false",
+	}
+
+	if len(clientCfg.Inject.Category) != 0 {
+		options["category"] = clientCfg.Inject.Category
+	}
+
+	if len(clientCfg.Inject.Tags) != 0 {
+		options["tags"] = strings.Join(clientCfg.Inject.Tags, ",")
+	}
+
+	if len(pack.torrentBytes) != 0 {
+		// WebAPI versions before 2.11 use paused, later ones stopped
+		options["paused"] = "true"
+		options["stopped"] = "true"
+
+		err = p.req.Client.AddTorrentFromMemoryCtx(ctx, pack.torrentBytes, options)
+	} else {
+		// a stopped magnet link never receives its metadata, the stop condition stops it once the metadata arrived
+		if err = p.checkStopCondition(ctx); err != nil {
+			return err
+		}
+		options["stopCondition"] = "MetadataReceived"
+
+		err = p.req.Client.AddTorrentFromUrlCtx(ctx, pack.magnet, options)
+	}
+	if err != nil {
+		return errors.Wrap(err, "could not add torrent")
+	}
+	p.log.Info().Msgf("added season pack to client: %s", pack.hash)
+
+	if _, err = p.waitForMetadata(ctx, pack.hash); err != nil {
+		return err
+	}
+
+	if err = p.req.Client.RecheckCtx(ctx, []string{pack.hash}); err != nil {
+		return errors.Wrap(err, "could not recheck torrent")
+	}
+
+	p.injected = pack.hash
+
+	client := p.req.Client
+
+	waitCtx, cancel := context.WithTimeout(ctx, recheckResponseTimeout)
+	torrent, err := waitForRecheck(waitCtx, client, pack.hash)
+	cancel()
+
+	if err != nil && waitCtx.Err() != nil {
+		p.log.Debug().Msgf("recheck is still running, watching it in the background: %s", pack.hash)
+		p.recheck = &recheckReport{State: recheckStatePending}
+
+		go p.watchRecheck(client, clientCfg, pack.hash)
+		return nil
+	}
+
+	p.recheck = p.reportRecheck(client, pack.hash, torrent, err)
+
+	return nil
+}
+
+// watchRecheck waits for the recheck of the injected season pack and reports its result.
+func (p *processor) watchRecheck(client *qbittorrent.Client, clientCfg *domain.Client, hash string) {
+	timeout := time.Duration(clientCfg.Inject.RecheckTimeout) * time.Minute
+	if timeout <= 0 {
+		timeout = time.Duration(domain.DefaultRecheckTimeout) * time.Minute
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	torrent, err := waitForRecheck(ctx, client, hash)
+	p.reportRecheck(client, hash, torrent, err)
+}
+
+// reportRecheck handles the finished recheck of the injected season pack, resumes it if it verified completely and
+// sends a notification about the result. The pack is only tagged and the incomplete action is only applied if
+// recheck is enabled.
+func (p *processor) reportRecheck(client *qbittorrent.Client, hash string, torrent qbittorrent.Torrent, err error,
+) *recheckReport {
+	// the result is handled even if the request is done or the timeout is almost reached
+	ctx := context.Background()
+
+	statusCode := domain.StatusRecheckError
+	report := &recheckReport{State: recheckStateError}
+
+	if err != nil {
+		err = errors.Wrap(err, statusCode.String())
+		p.log.Error().Err(err).Msgf("error watching recheck: %s", hash)
+	} else {
+		if p.cfg.Config.Recheck.Enabled {
+			statusCode, err = handleRecheckResult(ctx, p.log, client, p.cfg.Config.Recheck, torrent)
+		} else if torrent.Progress < 1 {
+			statusCode = domain.StatusRecheckIncomplete
+			p.log.Info().Msgf("season pack is %.2f%% complete after recheck: %s", torrent.Progress*100, hash)
+		} else {
+			statusCode = domain.StatusRecheckVerified
+			p.log.Info().Msgf("season pack verified after recheck: %s", hash)
+		}
+		report = &recheckReport{State: recheckStateIncomplete, Progress: torrent.Progress}
+
+		if statusCode == domain.StatusRecheckVerified {
+			report.State = recheckStateVerified
+
+			if resumeErr := client.ResumeCtx(ctx, []string{hash}); resumeErr != nil {
+				p.log.Error().Err(resumeErr).Msgf("error resuming torrent: %s", hash)
+				if err == nil {
+					err = errors.Wrap(resumeErr, "could not resume torrent")
+				}
+			}
+		}
+	}
+
+	if sendErr := p.noti.Send(statusCode, domain.NotificationPayload{
+		ReleaseName: p.req.Name,
+		Client:      p.req.ClientName,
+		Action:      "Inject",
+		Error:       err,
+	}); sendErr != nil {
+		p.log.Error().Err(sendErr).Msgf("error sending %s notification for %d", p.noti.Name(), statusCode)
+	}
+
+	return report
+}
+
+// waitForRecheck polls the client until the torrent finished checking and returns it with its final progress.
+func waitForRecheck(ctx context.Context, client *qbittorrent.Client, hash string) (qbittorrent.Torrent, error) {
+	ticker := time.NewTicker(recheckPollInterval)
+	defer ticker.Stop()
+
+	started := false
+	startDeadline := time.Now().Add(recheckStartTimeout)
+
+	for {
+		found, err := client.GetTorrentsCtx(ctx, qbittorrent.TorrentFilterOptions{Hashes: []string{hash}})
+		if err != nil && ctx.Err() == nil {
+			return qbittorrent.Torrent{}, err
+		}
+
+		if err == nil {
+			if len(found) == 0 {
+				return qbittorrent.Torrent{}, fmt.Errorf("torrent was removed from client")
+			}
+
+			checking := isChecking(found[0].State)
+			started = started || checking
+
+			if !checking && (started || time.Now().After(startDeadline)) {
+				return found[0], nil
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return qbittorrent.Torrent{}, fmt.Errorf("recheck didn't finish in time")
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,217 @@
+// Copyright (c) 2023 - 2024, nuxen and the seasonpackarr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package http
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nuxencs/seasonpackarr/internal/config"
+	"github.com/nuxencs/seasonpackarr/internal/domain"
+
+	"github.com/autobrr/go-qbittorrent"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_waitForRecheck(t *testing.T) {
+	const hash = "0123456789abcdef0123456789abcdef01234567"
+
+	tests := []struct {
+		name         string
+		states       [][]qbittorrent.Torrent
+		wantProgress float64
+		wantErr      bool
+	}{
+		{
+			name: "verified",
+			states: [][]qbittorrent.Torrent{
+				{{Hash: hash, State: qbittorrent.TorrentStateCheckingUp, Progress: 0.2}},
+				{{Hash: hash, State: qbittorrent.TorrentStateStalledUp, Progress: 1}},
+			},
+			wantProgress: 1,
+		},
+		{
+			name: "incomplete",
+			states: [][]qbittorrent.Torrent{
+				{{Hash: hash, State: qbittorrent.TorrentStateCheckingResumeData}},
+				{{Hash: hash, State: qbittorrent.TorrentStateStalledDl, Progress: 0.9}},
+			},
+			wantProgress: 0.9,
+		},
+		{
+			name:    "removed",
+			states:  [][]qbittorrent.Torrent{{}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, client := newFakeClient(t, tt.states...)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+
+			got, err := waitForRecheck(ctx, client, hash)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.InDelta(t, tt.wantProgress, got.Progress, 0.001)
+		})
+	}
+}
+
+func Test_processor_watchRecheck(t *testing.T) {
+	const hash = "0123456789abcdef0123456789abcdef01234567"
+
+	fc, client := newFakeClient(t,
+		[]qbittorrent.Torrent{{Hash: hash, State: qbittorrent.TorrentStateCheckingUp, Progress: 0.2}},
+		[]qbittorrent.Torrent{{Hash: hash, State: qbittorrent.TorrentStateStoppedDl, Progress: 0.9}},
+	)
+	noti := &fakeSender{}
+
+	p := &processor{
+		log: zerolog.Nop(),
+		cfg: &config.AppConfig{Config: &domain.Config{Recheck: domain.Recheck{
+			Enabled:          true,
+			IncompleteTag:    "incomplete",
+			IncompleteAction: domain.IncompleteActionNone,
+		}}},
+		noti: noti,
+		req:  &request{Name: "Series.Title.S01.1080p.WEB-DL.DDP5.1.H.264-RlsGrp"},
+	}
+
+	p.watchRecheck(client, &domain.Client{Inject: domain.Inject{RecheckTimeout: 1}}, hash)
+
+	assert.Equal(t, []domain.StatusCode{domain.StatusRecheckIncomplete}, noti.statusCodes)
+	assert.Equal(t, []string{"/api/v2/torrents/addTags?hashes=" + hash + "&tags=incomplete"}, fc.requests)
+}
+
+func Test_processor_injectSeasonPack(t *testing.T) {
+	const hash = "0123456789abcdef0123456789abcdef01234567"
+
+	added := qbittorrent.Torrent{Hash: hash, State: qbittorrent.TorrentStateStoppedDl, Size: 1024}
+
+	tests := []struct {
+		name            string
+		magnet          bool
+		recheckDisabled bool
+		result          qbittorrent.Torrent
+		wantRecheck     *recheckReport
+		wantStatus      domain.StatusCode
+		wantRequests    []string
+	}{
+		{
+			name:        "verified",
+			result:      qbittorrent.Torrent{Hash: hash, State: qbittorrent.TorrentStateStoppedUp, Progress: 1},
+			wantRecheck: &recheckReport{State: recheckStateVerified, Progress: 1},
+			wantStatus:  domain.StatusRecheckVerified,
+			wantRequests: []string{
+				"/api/v2/torrents/addTags?hashes=" + hash + "&tags=verified",
+				"/api/v2/torrents/start?hashes=" + hash,
+			},
+		},
+		{
+			name:        "incomplete",
+			result:      qbittorrent.Torrent{Hash: hash, State: qbittorrent.TorrentStateStoppedDl, Progress: 0.5},
+			wantRecheck: &recheckReport{State: recheckStateIncomplete, Progress: 0.5},
+			wantStatus:  domain.StatusRecheckIncomplete,
+			wantRequests: []string{
+				"/api/v2/torrents/start?hashes=" + hash,
+				"/api/v2/torrents/addTags?hashes=" + hash + "&tags=incomplete",
+			},
+		},
+		{
+			name:            "verified_recheck_disabled",
+			recheckDisabled: true,
+			result:          qbittorrent.Torrent{Hash: hash, State: qbittorrent.TorrentStateStoppedUp, Progress: 1},
+			wantRecheck:     &recheckReport{State: recheckStateVerified, Progress: 1},
+			wantStatus:      domain.StatusRecheckVerified,
+			wantRequests: []string{
+				"/api/v2/torrents/start?hashes=" + hash,
+			},
+		},
+		{
+			name:            "incomplete_recheck_disabled",
+			recheckDisabled: true,
+			result:          qbittorrent.Torrent{Hash: hash, State: qbittorrent.TorrentStateStoppedDl, Progress: 0.5},
+			wantRecheck:     &recheckReport{State: recheckStateIncomplete, Progress: 0.5},
+			wantStatus:      domain.StatusRecheckIncomplete,
+		},
+		{
+			name:        "magnet_verified",
+			magnet:      true,
+			result:      qbittorrent.Torrent{Hash: hash, State: qbittorrent.TorrentStateStoppedUp, Progress: 1},
+			wantRecheck: &recheckReport{State: recheckStateVerified, Progress: 1},
+			wantStatus:  domain.StatusRecheckVerified,
+			wantRequests: []string{
+				"/api/v2/torrents/addTags?hashes=" + hash + "&tags=verified",
+				"/api/v2/torrents/start?hashes=" + hash,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clientName := "inject_" + tt.name
+
+			fc, client := newFakeClient(t,
+				[]qbittorrent.Torrent{},
+				[]qbittorrent.Torrent{added},
+				[]qbittorrent.Torrent{{Hash: hash, State: qbittorrent.TorrentStateCheckingUp, Progress: 0.2}},
+				[]qbittorrent.Torrent{tt.result},
+			)
+			clientMap.Store(clientName, client)
+			t.Cleanup(func() { clientMap.Delete(clientName) })
+
+			noti := &fakeSender{}
+			p := &processor{
+				log: zerolog.Nop(),
+				cfg: &config.AppConfig{Config: &domain.Config{
+					MetadataTimeout: 10,
+					Recheck: domain.Recheck{
+						Enabled:          !tt.recheckDisabled,
+						VerifiedTag:      "verified",
+						IncompleteTag:    "incomplete",
+						IncompleteAction: domain.IncompleteActionResume,
+					},
+				}},
+				noti: noti,
+				req:  &request{Name: "Series.Title.S01.1080p.WEB-DL.DDP5.1.H.264-RlsGrp"},
+			}
+
+			clientCfg := &domain.Client{PreImportPath: "/data/pre-import"}
+			pack := seasonPack{hash: hash, torrentBytes: []byte("d4:infod4:name4:packee")}
+			if tt.magnet {
+				pack = seasonPack{hash: hash, magnet: "magnet:?xt=urn:btih:" + hash}
+			}
+
+			require.NoError(t, p.injectSeasonPack(context.Background(), clientCfg, clientName, pack))
+			assert.Equal(t, hash, p.injected)
+			assert.Equal(t, tt.wantRecheck, p.recheck)
+			assert.Equal(t, []domain.StatusCode{tt.wantStatus}, noti.statusCodes)
+
+			// the pack is added stopped, or stops once its metadata arrived, and is only started once it verified
+			require.Len(t, fc.requests, 2+len(tt.wantRequests))
+			assert.Contains(t, fc.requests[0], "/api/v2/torrents/add?")
+			if tt.magnet {
+				assert.NotContains(t, fc.requests[0], "stopped=")
+				assert.Contains(t, fc.requests[0], "stopCondition=MetadataReceived")
+			} else {
+				assert.Contains(t, fc.requests[0], "paused=true")
+				assert.Contains(t, fc.requests[0], "stopped=true")
+			}
+			assert.Equal(t, "/api/v2/torrents/recheck?hashes="+hash, fc.requests[1])
+			if len(tt.wantRequests) == 0 {
+				assert.Empty(t, fc.requests[2:])
+			} else {
+				assert.Equal(t, tt.wantRequests, fc.requests[2:])
+			}
+		})
+	}
+}
@@ -0,0 +1,272 @@
+// Copyright (c) 2023 - 2024, nuxen and the seasonpackarr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package http
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nuxencs/seasonpackarr/internal/domain"
+	"github.com/nuxencs/seasonpackarr/internal/torrents"
+	"github.com/nuxencs/seasonpackarr/internal/utils"
+	"github.com/nuxencs/seasonpackarr/pkg/errors"
+
+	"github.com/anacrolix/torrent/metainfo"
+	"github.com/autobrr/go-qbittorrent"
+)
+
+const (
+	// metadataPollInterval is how often the client is asked whether the metadata of a magnet link has arrived.
+	metadataPollInterval = time.Second
+	// removalTimeout is how long to wait for the client to remove a torrent that was added to read its metadata.
+	removalTimeout = 30 * time.Second
+	// minStopConditionVersion is the WebAPI version of qBittorrent 4.5, the first one with stop conditions.
+	minStopConditionVersion = "2.8.18"
+)
+
+// seasonPack is the layout of the announced season pack, either parsed from the torrent file or read from the client.
+type seasonPack struct {
+	name  string
+	isDir bool
+	files []torrents.File
+	// info holds the pieces of the season pack, nil if only the file list is known
+	info *metainfo.Info
+	// hash is the infohash of the season pack
+	hash string
+	// torrentBytes holds the torrent file, nil if the season pack was read from a magnet link
+	torrentBytes []byte
+	// magnet is the magnet link of the season pack, empty if it was parsed from the torrent file
+	magnet string
+}
+
+// getSeasonPack returns the season pack of the request. Requests with a magnet link or an infohash, but without
+// a torrent file, read the file list from the client instead of parsing the torrent.
+func (p *processor) getSeasonPack(ctx context.Context, clientCfg *domain.Client, clientName string,
+) (seasonPack, domain.StatusCode, error) {
+	hasTorrent := len(p.req.torrentBytes) != 0 || len(p.req.Torrent) != 0 || len(p.req.TorrentURL) != 0
+	if !hasTorrent && (len(p.req.Magnet) != 0 || len(p.req.InfoHash) != 0) {
+		return p.getSeasonPackFromClient(ctx, clientCfg, clientName)
+	}
+
+	torrentBytes, statusCode, err := p.getTorrentBytes(ctx)
+	if err != nil {
+		return seasonPack{}, statusCode, err
+	}
+
+	torrentInfo, err := torrents.ParseInfoFromTorrentBytes(torrentBytes)
+	if err != nil {
+		return seasonPack{}, domain.StatusParseTorrentInfoError,
+			errors.Wrap(err, domain.StatusParseTorrentInfoError.String())
+	}
+
+	hash, err := torrents.GetInfoHashFromTorrentBytes(torrentBytes)
+	if err != nil {
+		return seasonPack{}, domain.StatusParseTorrentInfoError,
+			errors.Wrap(err, domain.StatusParseTorrentInfoError.String())
+	}
+
+	return seasonPack{
+		name:         torrentInfo.BestName(),
+		isDir:        torrentInfo.IsDir(),
+		files:        torrents.GetFilesFromTorrentInfo(torrentInfo),
+		info:         &torrentInfo,
+		hash:         hash,
+		torrentBytes: torrentBytes,
+	}, domain.StatusSuccessfulMatch, nil
+}
+
+// getTargetPackDir returns the folder the season pack is linked to. The pack name and every file path come from
+// a user supplied torrent, so all of them have to stay inside the pre import path, otherwise the pack is rejected.
+func getTargetPackDir(preImportPath string, pack seasonPack) (string, error) {
+	targetPackDir, err := utils.PackTargetPath(preImportPath, pack.name, "")
+	if err != nil {
+		return "", err
+	}
+
+	for _, file := range pack.files {
+		if _, err = utils.SafeJoin(targetPackDir, file.Path); err != nil {
+			return "", err
+		}
+	}
+
+	return targetPackDir, nil
+}
+
+// getSeasonPackFromClient adds the magnet link to the client, waits for the metadata and reads the file list.
+// The torrent is added with a stop condition, so no data is downloaded, and removed again afterward, unless it was
+// already in the client before.
+func (p *processor) getSeasonPackFromClient(ctx context.Context, clientCfg *domain.Client, clientName string,
+) (seasonPack, domain.StatusCode, error) {
+	magnet, err := p.getMagnet()
+	if err != nil {
+		return seasonPack{}, domain.StatusMetadataError, errors.Wrap(err, domain.StatusMetadataError.String())
+	}
+	hash := magnet.InfoHash.HexString()
+
+	if err = p.getClient(clientCfg, clientName); err != nil {
+		return seasonPack{}, domain.StatusGetClientError, errors.Wrap(err, domain.StatusGetClientError.String())
+	}
+
+	existing, err := p.req.Client.GetTorrentsCtx(ctx, qbittorrent.TorrentFilterOptions{Hashes: []string{hash}})
+	if err != nil {
+		return seasonPack{}, domain.StatusMetadataError, errors.Wrap(err, domain.StatusMetadataError.String())
+	}
+
+	if len(existing) == 0 {
+		if err = p.checkStopCondition(ctx); err != nil {
+			return seasonPack{}, domain.StatusMetadataError, errors.Wrap(err, domain.StatusMetadataError.String())
+		}
+
+		// a stopped torrent doesn't connect to any peers and never receives its metadata, the stop condition stops it
+		// right after the metadata arrived instead
+		if err = p.req.Client.AddTorrentFromUrlCtx(ctx, magnet.String(), map[string]string{
+			"stopCondition": "MetadataReceived",
+			"contentLayout": "Original",
+		}); err != nil {
+			return seasonPack{}, domain.StatusMetadataError, errors.Wrap(err, domain.StatusMetadataError.String())
+		}
+		p.log.Debug().Msgf("added magnet link to client to get metadata: %s", hash)
+
+		defer p.removeTorrent(hash)
+	}
+
+	torrent, err := p.waitForMetadata(ctx, hash)
+	if err != nil {
+		return seasonPack{}, domain.StatusMetadataError, errors.Wrap(err, domain.StatusMetadataError.String())
+	}
+
+	clientFiles, err := p.req.Client.GetFilesInformationCtx(ctx, hash)
+	if err != nil {
+		return seasonPack{}, domain.StatusMetadataError, errors.Wrap(err, domain.StatusMetadataError.String())
+	}
+
+	pack := seasonPack{
+		name:   torrent.Name,
+		files:  make([]torrents.File, 0, len(*clientFiles)),
+		hash:   hash,
+		magnet: magnet.String(),
+	}
+
+	for _, f := range *clientFiles {
+		// file names start with the root folder of the torrent, the paths in a torrent file don't
+		filePath, isDir := strings.CutPrefix(f.Name, torrent.Name+"/")
+		pack.isDir = pack.isDir || isDir
+
+		pack.files = append(pack.files, torrents.File{Path: filePath, Size: f.Size})
+	}
+
+	return pack, domain.StatusSuccessfulMatch, nil
+}
+
+// checkStopCondition returns an error if the client doesn't support stop conditions. Older clients ignore them and
+// would download the whole season pack of a magnet link.
+func (p *processor) checkStopCondition(ctx context.Context) error {
+	version, err := p.req.Client.GetWebAPIVersionCtx(ctx)
+	if err != nil {
+		return err
+	}
+
+	if compareVersions(version, minStopConditionVersion) < 0 {
+		return fmt.Errorf("magnet links need qBittorrent 4.5 or later, client has WebAPI version %s", version)
+	}
+
+	return nil
+}
+
+// compareVersions compares two dotted version numbers like 2.8.18, parts that aren't numbers count as zero.
+func compareVersions(a, b string) int {
+	partsA := strings.Split(strings.TrimSpace(a), ".")
+	partsB := strings.Split(strings.TrimSpace(b), ".")
+
+	for i := range max(len(partsA), len(partsB)) {
+		var numA, numB int
+		if i < len(partsA) {
+			numA, _ = strconv.Atoi(partsA[i])
+		}
+		if i < len(partsB) {
+			numB, _ = strconv.Atoi(partsB[i])
+		}
+
+		if c := cmp.Compare(numA, numB); c != 0 {
+			return c
+		}
+	}
+
+	return 0
+}
+
+// removeTorrent removes the torrent from the client and waits until it is gone, so the season pack can be injected
+// with the same infohash right after.
+func (p *processor) removeTorrent(hash string) {
+	ctx, cancel := context.WithTimeout(context.Background(), removalTimeout)
+	defer cancel()
+
+	if err := p.req.Client.DeleteTorrentsCtx(ctx, []string{hash}, false); err != nil {
+		p.log.Error().Err(err).Msgf("error removing torrent from client: %s", hash)
+		return
+	}
+
+	ticker := time.NewTicker(metadataPollInterval)
+	defer ticker.Stop()
+
+	for {
+		found, err := p.req.Client.GetTorrentsCtx(ctx, qbittorrent.TorrentFilterOptions{Hashes: []string{hash}})
+		if err == nil && len(found) == 0 {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			p.log.Error().Msgf("torrent wasn't removed from client within %s: %s", removalTimeout, hash)
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// getMagnet returns the magnet link of the request, built from the infohash if no magnet link was passed.
+func (p *processor) getMagnet() (metainfo.Magnet, error) {
+	if len(p.req.Magnet) != 0 {
+		return metainfo.ParseMagnetUri(p.req.Magnet)
+	}
+
+	var infoHash metainfo.Hash
+	if err := infoHash.FromHexString(p.req.InfoHash); err != nil {
+		return metainfo.Magnet{}, errors.Wrap(err, "invalid infohash: %s", p.req.InfoHash)
+	}
+
+	return metainfo.Magnet{InfoHash: infoHash, DisplayName: p.req.Name}, nil
+}
+
+// waitForMetadata polls the client until the metadata of the torrent is available or the timeout is reached.
+func (p *processor) waitForMetadata(ctx context.Context, hash string) (qbittorrent.Torrent, error) {
+	timeout := time.Duration(p.cfg.Config.MetadataTimeout) * time.Second
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(metadataPollInterval)
+	defer ticker.Stop()
+
+	for {
+		found, err := p.req.Client.GetTorrentsCtx(ctx, qbittorrent.TorrentFilterOptions{Hashes: []string{hash}})
+		if err != nil && ctx.Err() == nil {
+			return qbittorrent.Torrent{}, err
+		}
+
+		if len(found) != 0 && found[0].State != qbittorrent.TorrentStateMetaDl && found[0].Size > 0 {
+			return found[0], nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return qbittorrent.Torrent{}, fmt.Errorf("no metadata received within %s", timeout)
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,255 @@
+// Copyright (c) 2023 - 2024, nuxen and the seasonpackarr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package http
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nuxencs/seasonpackarr/internal/config"
+	"github.com/nuxencs/seasonpackarr/internal/domain"
+	"github.com/nuxencs/seasonpackarr/internal/torrents"
+
+	"github.com/anacrolix/torrent/bencode"
+	"github.com/anacrolix/torrent/metainfo"
+	"github.com/autobrr/go-qbittorrent"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mustTorrentBytes(f *testing.F, name string, paths ...[]string) []byte {
+	info := metainfo.Info{Name: name, PieceLength: 1 << 14}
+	for _, path := range paths {
+		info.Files = append(info.Files, metainfo.FileInfo{Path: path, Length: 1})
+	}
+
+	infoBytes, err := bencode.Marshal(info)
+	if err != nil {
+		f.Fatal(err)
+	}
+
+	torrentBytes, err := bencode.Marshal(metainfo.MetaInfo{InfoBytes: infoBytes})
+	if err != nil {
+		f.Fatal(err)
+	}
+
+	return torrentBytes
+}
+
+func Fuzz_getTargetPackDir(f *testing.F) {
+	f.Add(mustTorrentBytes(f, "Series.Title.S01.1080p.WEB-DL.DDP5.1.H.264-RlsGrp",
+		[]string{"Series.Title.S01E01.mkv"}, []string{"Subs", "Series.Title.S01E01.srt"}))
+	f.Add(mustTorrentBytes(f, "..", []string{"Series.Title.S01E01.mkv"}))
+	f.Add(mustTorrentBytes(f, "Series", []string{"..", "..", "etc", "passwd"}))
+	f.Add(mustTorrentBytes(f, "Series", []string{"Subs/../../file.mkv"}))
+	f.Add(mustTorrentBytes(f, "Series", []string{`..\..\file.mkv`}))
+	f.Add(mustTorrentBytes(f, "Series", []string{"/etc/passwd"}))
+	f.Add(mustTorrentBytes(f, "/etc/passwd"))
+
+	const preImportPath = "/data/pre"
+
+	f.Fuzz(func(t *testing.T, torrentBytes []byte) {
+		info, err := torrents.ParseInfoFromTorrentBytes(torrentBytes)
+		if err != nil {
+			return
+		}
+
+		pack := seasonPack{
+			name:  info.BestName(),
+			isDir: info.IsDir(),
+			files: torrents.GetFilesFromTorrentInfo(info),
+			info:  &info,
+		}
+
+		targetPackDir, err := getTargetPackDir(preImportPath, pack)
+		if err != nil {
+			return
+		}
+
+		if filepath.Dir(targetPackDir) != preImportPath {
+			t.Fatalf("pack folder %q is not directly below %q", targetPackDir, preImportPath)
+		}
+
+		for _, file := range pack.files {
+			targetPath := filepath.Join(targetPackDir, file.Path)
+
+			rel, err := filepath.Rel(targetPackDir, targetPath)
+			if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, "../") {
+				t.Fatalf("target %q of %q escapes pack folder %q", targetPath, file.Path, targetPackDir)
+			}
+		}
+	})
+}
+
+func Test_processor_getSeasonPackFromClient(t *testing.T) {
+	const hash = "0123456789abcdef0123456789abcdef01234567"
+
+	ready := qbittorrent.Torrent{Hash: hash, Name: "Series.Title.S01", State: qbittorrent.TorrentStateStoppedDl, Size: 300}
+
+	tests := []struct {
+		name          string
+		webAPIVersion string
+		torrents      [][]qbittorrent.Torrent
+		wantCalls     int
+		wantAdded     bool
+		wantRequests  []string
+		wantErr       bool
+	}{
+		{
+			name: "added_and_removed",
+			torrents: [][]qbittorrent.Torrent{
+				{},
+				{{Hash: hash, State: qbittorrent.TorrentStateMetaDl}},
+				{ready},
+				// the client takes a moment to remove the torrent
+				{ready},
+				{},
+			},
+			wantCalls:    5,
+			wantAdded:    true,
+			wantRequests: []string{"/api/v2/torrents/delete?deleteFiles=false&hashes=" + hash},
+		},
+		{
+			name:      "already_in_client",
+			torrents:  [][]qbittorrent.Torrent{{ready}},
+			wantCalls: 2,
+		},
+		{
+			// qBittorrent 4.4 doesn't know stop conditions and would download the whole pack
+			name:          "without_stop_condition",
+			webAPIVersion: "2.8.5",
+			torrents:      [][]qbittorrent.Torrent{{}},
+			wantCalls:     1,
+			wantErr:       true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clientName := "metadata_" + tt.name
+
+			fc, client := newFakeClient(t, tt.torrents...)
+			fc.webAPIVersion = tt.webAPIVersion
+			fc.files = `[{"name":"Series.Title.S01/Series.Title.S01E01.mkv","size":100},
+				{"name":"Series.Title.S01/Series.Title.S01E02.mkv","size":200}]`
+			clientMap.Store(clientName, client)
+			t.Cleanup(func() { clientMap.Delete(clientName) })
+
+			p := &processor{
+				log: zerolog.Nop(),
+				cfg: &config.AppConfig{Config: &domain.Config{MetadataTimeout: 10}},
+				req: &request{Name: "Series.Title.S01", Magnet: "magnet:?xt=urn:btih:" + hash + "&dn=Series.Title.S01"},
+			}
+
+			pack, statusCode, err := p.getSeasonPackFromClient(context.Background(), &domain.Client{}, clientName)
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Equal(t, domain.StatusMetadataError, statusCode)
+
+				fc.mu.Lock()
+				defer fc.mu.Unlock()
+
+				assert.Equal(t, tt.wantCalls, fc.calls)
+				assert.Empty(t, fc.requests, "nothing is added to the client")
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, domain.StatusSuccessfulMatch, statusCode)
+
+			assert.Equal(t, "Series.Title.S01", pack.name)
+			assert.True(t, pack.isDir)
+			assert.Equal(t, hash, pack.hash)
+			assert.Equal(t, []torrents.File{
+				{Path: "Series.Title.S01E01.mkv", Size: 100},
+				{Path: "Series.Title.S01E02.mkv", Size: 200},
+			}, pack.files)
+
+			fc.mu.Lock()
+			defer fc.mu.Unlock()
+
+			assert.Equal(t, tt.wantCalls, fc.calls)
+
+			requests := fc.requests
+			if tt.wantAdded {
+				// a stopped torrent never receives its metadata, the stop condition keeps it from downloading
+				require.NotEmpty(t, requests)
+				assert.Contains(t, requests[0], "/api/v2/torrents/add?")
+				assert.NotContains(t, requests[0], "paused=")
+				assert.NotContains(t, requests[0], "stopped=")
+				assert.Contains(t, requests[0], "stopCondition=MetadataReceived")
+				requests = requests[1:]
+			}
+			assert.Equal(t, tt.wantRequests, requests)
+		})
+	}
+}
+
+func Test_processor_waitForMetadata(t *testing.T) {
+	const hash = "0123456789abcdef0123456789abcdef01234567"
+
+	tests := []struct {
+		name     string
+		torrents [][]qbittorrent.Torrent
+		wantErr  bool
+	}{
+		{
+			name: "received",
+			torrents: [][]qbittorrent.Torrent{
+				{{Hash: hash, State: qbittorrent.TorrentStateMetaDl}},
+				{{Hash: hash, State: qbittorrent.TorrentStateStoppedDl, Size: 1024}},
+			},
+		},
+		{
+			name:     "not_received",
+			torrents: [][]qbittorrent.Torrent{{{Hash: hash, State: qbittorrent.TorrentStateMetaDl}}},
+			wantErr:  true,
+		},
+		{
+			name:     "without_size",
+			torrents: [][]qbittorrent.Torrent{{{Hash: hash, State: qbittorrent.TorrentStateStoppedDl}}},
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, client := newFakeClient(t, tt.torrents...)
+
+			p := &processor{
+				cfg: &config.AppConfig{Config: &domain.Config{MetadataTimeout: 2}},
+				req: &request{Client: client},
+			}
+
+			got, err := p.waitForMetadata(context.Background(), hash)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, int64(1024), got.Size)
+		})
+	}
+}
+
+func Test_compareVersions(t *testing.T) {
+	tests := []struct {
+		name string
+		a    string
+		b    string
+		want int
+	}{
+		{name: "equal", a: "2.8.18", b: "2.8.18", want: 0},
+		{name: "older_patch", a: "2.8.5", b: "2.8.18", want: -1},
+		{name: "newer_minor", a: "2.11.2", b: "2.8.18", want: 1},
+		{name: "missing_patch", a: "2.9", b: "2.9.0", want: 0},
+		{name: "trailing_newline", a: "2.8.18\n", b: "2.8.18", want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, compareVersions(tt.a, tt.b))
+		})
+	}
+}
@@ -4,20 +4,27 @@
 package http
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
+	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/nuxencs/seasonpackarr/internal/config"
 	"github.com/nuxencs/seasonpackarr/internal/domain"
+	"github.com/nuxencs/seasonpackarr/internal/janitor"
 	"github.com/nuxencs/seasonpackarr/internal/logger"
 	"github.com/nuxencs/seasonpackarr/internal/release"
 	"github.com/nuxencs/seasonpackarr/internal/torrents"
 	"github.com/nuxencs/seasonpackarr/internal/utils"
 	"github.com/nuxencs/seasonpackarr/pkg/errors"
 
+	"github.com/anacrolix/torrent/metainfo"
 	"github.com/autobrr/go-qbittorrent"
 	"github.com/gin-gonic/gin"
 	"github.com/moistari/rls"
@@ -26,17 +33,38 @@ import (
 )
 
 type processor struct {
-	log  zerolog.Logger
-	cfg  *config.AppConfig
-	noti domain.Sender
-	req  *request
+	log       zerolog.Logger
+	cfg       *config.AppConfig
+	noti      domain.Sender
+	req       *request
+	readiness *torrents.Readiness
+	links     []linkReport
+	conflicts int
+	folders   *janitor.Store
+	// injected is the infohash of the season pack if it was added to the client by seasonpackarr
+	injected string
+	// recheck is the state of the recheck of the injected season pack
+	recheck *recheckReport
+}
+
+// linkReport describes how a file of the season pack was linked into the pre import path.
+type linkReport struct {
+	Source   string `json:"source"`
+	Target   string `json:"target"`
+	Strategy string `json:"strategy"`
 }
 
 type request struct {
 	Name       string
 	Torrent    json.RawMessage
+	TorrentURL string `json:"torrentUrl"`
+	Magnet     string
+	InfoHash   string
+	Category   string
 	Client     *qbittorrent.Client
 	ClientName string
+	// torrentBytes holds the raw torrent file, either uploaded directly or decoded from Torrent or TorrentURL
+	torrentBytes []byte
 }
 
 type entry struct {
@@ -45,17 +73,37 @@ type entry struct {
 }
 
 type torrentRlsEntries struct {
-	entriesMap  map[string][]entry
+	// entriesMap holds the torrents that pass the filters of the client, grouped by their formatted title
+	entriesMap map[string][]entry
+	// excludedMap holds the torrents that don't pass the filters, it is only known if the torrent list is synced
+	excludedMap map[string][]entry
 	rlsMap      map[string]rls.Release
 	lastUpdated time.Time
 	err         error
 	sync.Mutex
 }
 
+// episodeKey identifies an episode independent of the client torrent it was found in.
+type episodeKey struct {
+	season  int
+	episode int
+}
+
 type matchInfo struct {
 	clientEpPath    string
 	clientEpSize    int64
 	announcedEpPath string
+	// episode is the season and episode parsed from the file, zero if it couldn't be parsed
+	episode episodeKey
+	// companionOf is the client path of the episode a companion file belongs to, empty for episodes
+	companionOf string
+}
+
+// linkInfo is a file in the client that gets linked to a file in the season pack torrent.
+type linkInfo struct {
+	clientPath  string
+	torrentPath string
+	companion   bool
 }
 
 var (
@@ -64,15 +112,17 @@ var (
 	torrentMap = xsync.NewMapOf[string, *torrentRlsEntries]()
 )
 
-func newProcessor(log logger.Logger, config *config.AppConfig, notification domain.Sender) *processor {
+func newProcessor(log logger.Logger, config *config.AppConfig, notification domain.Sender, folders *janitor.Store) *processor {
 	return &processor{
-		log:  log.With().Str("module", "processor").Logger(),
-		cfg:  config,
-		noti: notification,
+		log:     log.With().Str("module", "processor").Logger(),
+		cfg:     config,
+		noti:    notification,
+		folders: folders,
 	}
 }
 
-func (p *processor) getClient(client *domain.Client, clientName string) error {
+// getClient returns the logged in client with the given name, creating it on first use.
+func getClient(client *domain.Client, clientName string) (*qbittorrent.Client, error) {
 	c, ok := clientMap.Load(clientName)
 	if !ok {
 		clientCfg := qbittorrent.Config{
@@ -84,17 +134,44 @@ func (p *processor) getClient(client *domain.Client, clientName string) error {
 		c = qbittorrent.NewClient(clientCfg)
 
 		if err := c.Login(); err != nil {
-			return errors.Wrap(err, "failed to login to qbittorrent")
+			return nil, errors.Wrap(err, "failed to login to qbittorrent")
 		}
 
 		clientMap.Store(clientName, c)
 	}
 
+	return c, nil
+}
+
+// ListTorrents returns all torrents of the client with the given name.
+func ListTorrents(ctx context.Context, cfg *config.AppConfig, clientName string) ([]qbittorrent.Torrent, error) {
+	client, ok := cfg.Config.Clients[clientName]
+	if !ok {
+		return nil, domain.StatusClientNotFound.Error()
+	}
+
+	c, err := getClient(client, clientName)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.GetTorrentsCtx(ctx, qbittorrent.TorrentFilterOptions{})
+}
+
+func (p *processor) getClient(client *domain.Client, clientName string) error {
+	c, err := getClient(client, clientName)
+	if err != nil {
+		return err
+	}
+
 	p.req.Client = c
 	return nil
 }
 
-func (p *processor) getAllTorrents(clientName string) torrentRlsEntries {
+// getAllTorrents returns the torrents of the client that pass its filters. The filters are applied by the client as far
+// as it supports them, so large clients don't have to send their whole torrent list.
+func (p *processor) getAllTorrents(ctx context.Context, clientCfg *domain.Client, clientName string,
+) torrentRlsEntries {
 	f := func() *torrentRlsEntries {
 		tre, ok := torrentMap.Load(clientName)
 		if ok {
@@ -120,13 +197,25 @@ func (p *processor) getAllTorrents(clientName string) torrentRlsEntries {
 		return *entries
 	}
 
-	ts, err := p.req.Client.GetTorrents(qbittorrent.TorrentFilterOptions{})
+	ts, err := getFilteredTorrents(ctx, p.req.Client, clientCfg.Filters)
 	if err != nil {
 		return torrentRlsEntries{err: err}
 	}
 
 	after := time.Now()
-	entries = &torrentRlsEntries{entriesMap: make(map[string][]entry), lastUpdated: after.Add(after.Sub(cur)), rlsMap: entries.rlsMap}
+	entries = newTorrentRlsEntries(ts, entries.rlsMap, p.cfg.Config.SeparateLanguages, after.Add(after.Sub(cur)))
+	getFileCache(clientName).retain(ts)
+
+	torrentMap.Store(clientName, entries)
+	return *entries
+}
+
+// newTorrentRlsEntries groups the torrents by their formatted title. Every release name is only parsed once and
+// cached in rlsMap.
+func newTorrentRlsEntries(ts []qbittorrent.Torrent, rlsMap map[string]rls.Release, separateLanguages bool,
+	lastUpdated time.Time,
+) *torrentRlsEntries {
+	entries := &torrentRlsEntries{entriesMap: make(map[string][]entry), lastUpdated: lastUpdated, rlsMap: rlsMap}
 
 	for _, t := range ts {
 		r, ok := entries.rlsMap[t.Name]
@@ -135,16 +224,463 @@ func (p *processor) getAllTorrents(clientName string) torrentRlsEntries {
 			entries.rlsMap[t.Name] = r
 		}
 
-		fmtTitle := utils.GetFormattedTitle(r)
+		fmtTitle := utils.GetFormattedTitle(r, separateLanguages)
 		entries.entriesMap[fmtTitle] = append(entries.entriesMap[fmtTitle], entry{t: t, r: r})
 	}
 
-	torrentMap.Store(clientName, entries)
-	return *entries
+	return entries
+}
+
+// checkAlreadyInClient returns StatusAlreadyInClient if the season pack is one of the torrents. The filters only limit
+// the torrents that are linked, so with filters set the pack is also looked up by its infohash, unless the torrents
+// that don't pass the filters are known from the synced torrent list.
+func (p *processor) checkAlreadyInClient(ctx context.Context, requestRls rls.Release,
+	fuzzyMatching domain.FuzzyMatching, filters domain.TorrentFilters, entries []entry, allKnown bool,
+) (domain.StatusCode, error) {
+	for _, clientEntry := range entries {
+		if release.CheckCandidates(requestRls, clientEntry.r, fuzzyMatching).StatusCode == domain.StatusAlreadyInClient {
+			return domain.StatusAlreadyInClient, domain.StatusAlreadyInClient.Error()
+		}
+	}
+
+	if allKnown || !hasFilters(filters) {
+		return domain.StatusSuccessfulMatch, nil
+	}
+
+	hash := p.getRequestInfoHash(ctx)
+	if len(hash) == 0 {
+		p.log.Debug().Msg("no infohash in request, only checked the filtered torrents for the season pack")
+		return domain.StatusSuccessfulMatch, nil
+	}
+
+	found, err := p.req.Client.GetTorrentsCtx(ctx, qbittorrent.TorrentFilterOptions{Hashes: []string{hash}})
+	if err != nil {
+		return domain.StatusGetTorrentsError, errors.Wrap(err, domain.StatusGetTorrentsError.String())
+	}
+
+	if len(found) != 0 {
+		return domain.StatusAlreadyInClient, domain.StatusAlreadyInClient.Error()
+	}
+
+	return domain.StatusSuccessfulMatch, nil
+}
+
+// getRequestInfoHash returns the infohash of the season pack if the request contains it, a magnet link or the
+// torrent file. Torrent urls aren't fetched for it.
+func (p *processor) getRequestInfoHash(ctx context.Context) string {
+	if len(p.req.InfoHash) != 0 || len(p.req.Magnet) != 0 {
+		magnet, err := p.getMagnet()
+		if err != nil {
+			p.log.Debug().Err(err).Msg("could not get infohash from request")
+			return ""
+		}
+
+		return magnet.InfoHash.HexString()
+	}
+
+	if len(p.req.torrentBytes) == 0 && len(p.req.Torrent) == 0 {
+		return ""
+	}
+
+	torrentBytes, _, err := p.getTorrentBytes(ctx)
+	if err != nil {
+		p.log.Debug().Err(err).Msg("could not decode torrent bytes to get the infohash")
+		return ""
+	}
+
+	hash, err := torrents.GetInfoHashFromTorrentBytes(torrentBytes)
+	if err != nil {
+		p.log.Debug().Err(err).Msg("could not get infohash from torrent")
+		return ""
+	}
+
+	return hash
+}
+
+// awaitCompletion waits for the incomplete torrent to finish downloading if the incomplete policy allows it.
+func (p *processor) awaitCompletion(ctx context.Context, t qbittorrent.Torrent) (qbittorrent.Torrent, error) {
+	if p.cfg.Config.IncompletePolicy != domain.IncompletePolicyWait {
+		return qbittorrent.Torrent{}, fmt.Errorf("torrent is incomplete")
+	}
+
+	p.log.Debug().Msgf("waiting for torrent to finish downloading: %s", t.Name)
+
+	return waitForCompletion(ctx, p.req.Client, t.Hash)
+}
+
+// getFiles returns the file list of the torrent, which is cached per client until the torrent changes.
+func (p *processor) getFiles(clientName string, t qbittorrent.Torrent) (*qbittorrent.TorrentFiles, error) {
+	return getFileCache(clientName).get(t, p.req.Client.GetFilesInformation)
+}
+
+// getEpisodeExtensions returns the episode file extensions of the client, falling back to the global ones.
+func (p *processor) getEpisodeExtensions(client *domain.Client) []string {
+	if len(client.EpisodeExtensions) != 0 {
+		return client.EpisodeExtensions
+	}
+
+	if len(p.cfg.Config.EpisodeExtensions) != 0 {
+		return p.cfg.Config.EpisodeExtensions
+	}
+
+	return domain.DefaultEpisodeExtensions
+}
+
+// createLink links the client file to the target path with the link strategies and directory options of the client
+// and records the strategy that was used.
+func (p *processor) createLink(client *domain.Client, srcPath, trgPath string) error {
+	opts := utils.LinkOptions{
+		Strategies: client.LinkStrategies,
+		Overwrite:  p.cfg.Config.ConflictPolicy == domain.ConflictPolicyOverwrite,
+	}
+
+	if len(opts.Strategies) == 0 {
+		opts.Strategies = domain.DefaultLinkStrategies
+	}
+
+	dirOpts, err := utils.NewDirOptions(client.DirMode, client.User, client.Group, client.Setgid)
+	if err != nil {
+		return err
+	}
+	opts.Dirs = dirOpts
+
+	strategy, err := utils.CreateLink(srcPath, trgPath, opts)
+	if err != nil {
+		if errors.Is(err, utils.ErrLinkConflict) {
+			p.conflicts++
+		}
+		return err
+	}
+
+	if strategy == utils.LinkExisting {
+		p.log.Log().Msgf("target already exists: source(%s), target(%s)", srcPath, trgPath)
+	} else {
+		p.log.Log().Msgf("created %s: source(%s), target(%s)", strategy, srcPath, trgPath)
+	}
+
+	p.links = append(p.links, linkReport{Source: srcPath, Target: trgPath, Strategy: strategy})
+
+	return nil
+}
+
+// trackFolder hands a season pack folder created by this request to the janitor, so it gets removed again if no
+// torrent ever claims it.
+func (p *processor) trackFolder(clientName, folderPath string) {
+	if p.folders == nil || !dirExists(folderPath) {
+		return
+	}
+
+	if err := p.folders.Track(clientName, folderPath); err != nil {
+		p.log.Error().Err(err).Msgf("error tracking folder: %s", folderPath)
+	}
+}
+
+func dirExists(dirPath string) bool {
+	fi, err := os.Stat(dirPath)
+	return err == nil && fi.IsDir()
+}
+
+// getFailedLinkStatus returns the status for a season pack without any linked episodes. Targets that already existed
+// with different content are reported separately, since linking them again won't help.
+func (p *processor) getFailedLinkStatus() domain.StatusCode {
+	if p.conflicts > 0 {
+		return domain.StatusLinkConflict
+	}
+
+	return domain.StatusFailedHardlink
+}
+
+func (p *processor) getSeasonPackHints(ctx context.Context, episodeExtensions []string) release.SeasonPackHints {
+	hints := release.SeasonPackHints{Category: p.req.Category, EpisodeExtensions: episodeExtensions}
+
+	// torrent urls are only fetched when parsing, classifying a pack shouldn't wait for a download
+	if len(p.req.torrentBytes) == 0 && len(p.req.Torrent) == 0 {
+		return hints
+	}
+
+	torrentBytes, _, err := p.getTorrentBytes(ctx)
+	if err != nil {
+		p.log.Debug().Err(err).Msg("could not decode torrent bytes, classifying without torrent files")
+		return hints
+	}
+
+	torrentInfo, err := torrents.ParseInfoFromTorrentBytes(torrentBytes)
+	if err != nil {
+		p.log.Debug().Err(err).Msg("could not parse torrent info, classifying without torrent files")
+		return hints
+	}
+
+	hints.FilePaths = torrents.GetFilePathsFromTorrentInfo(torrentInfo)
+	return hints
+}
+
+// getTorrentBytes returns the raw torrent file of the request. It is taken from the uploaded file, the encoded
+// torrent field or downloaded from the torrent url, in that order.
+func (p *processor) getTorrentBytes(ctx context.Context) ([]byte, domain.StatusCode, error) {
+	if len(p.req.torrentBytes) != 0 {
+		return p.req.torrentBytes, domain.StatusSuccessfulMatch, nil
+	}
+
+	switch {
+	case len(p.req.Torrent) != 0:
+		torrentBytes, err := torrents.DecodeTorrentBytes(p.req.Torrent)
+		if err != nil {
+			return nil, domain.StatusDecodeTorrentBytesError, errors.Wrap(err, domain.StatusDecodeTorrentBytesError.String())
+		}
+		p.req.torrentBytes = torrentBytes
+
+	case len(p.req.TorrentURL) != 0:
+		torrentBytes, err := torrents.FetchTorrent(ctx, p.req.TorrentURL, p.cfg.Config.TorrentURL.AllowedHosts)
+		if err != nil {
+			return nil, domain.StatusFetchTorrentError, errors.Wrap(err, domain.StatusFetchTorrentError.String())
+		}
+		p.req.torrentBytes = torrentBytes
+
+	default:
+		return nil, domain.StatusTorrentBytesError, domain.StatusTorrentBytesError.Error()
+	}
+
+	return p.req.torrentBytes, domain.StatusSuccessfulMatch, nil
+}
+
+// decodeRequest reads the request of the parse endpoint from a JSON body, from a multipart form with the torrent file
+// in the torrent field or from a raw torrent file body with the remaining fields passed as query parameters.
+func (p *processor) decodeRequest(c *gin.Context) error {
+	switch c.ContentType() {
+	case "multipart/form-data":
+		// leave some room for the other form fields
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, torrents.MaxTorrentSize+1<<20)
+
+		p.req = &request{
+			Name:       c.PostForm("name"),
+			TorrentURL: c.PostForm("torrentUrl"),
+			Magnet:     c.PostForm("magnet"),
+			InfoHash:   c.PostForm("infohash"),
+			Category:   c.PostForm("category"),
+			ClientName: c.PostForm("clientname"),
+		}
+
+		fileHeader, err := c.FormFile("torrent")
+		if err != nil {
+			if errors.Is(err, http.ErrMissingFile) {
+				return nil
+			}
+			return err
+		}
+
+		if fileHeader.Size > torrents.MaxTorrentSize {
+			return torrents.ErrTorrentTooLarge
+		}
+
+		f, err := fileHeader.Open()
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		p.req.torrentBytes, err = torrents.ReadTorrent(f)
+		return err
+
+	case "application/x-bittorrent":
+		p.req = &request{
+			Name:       c.Query("name"),
+			TorrentURL: c.Query("torrentUrl"),
+			Magnet:     c.Query("magnet"),
+			InfoHash:   c.Query("infohash"),
+			Category:   c.Query("category"),
+			ClientName: c.Query("clientname"),
+		}
+
+		var err error
+		p.req.torrentBytes, err = torrents.ReadTorrent(c.Request.Body)
+		return err
+
+	default:
+		return json.NewDecoder(c.Request.Body).Decode(&p.req)
+	}
+}
+
+func (p *processor) classifySeasonPack(requestRls rls.Release, hints release.SeasonPackHints) (domain.StatusCode, error) {
+	classification := release.ClassifySeasonPack(requestRls, hints)
+	p.log.Debug().Msgf("season pack classification: %s", classification.String())
+
+	if !classification.IsSeasonPack {
+		return domain.StatusNotASeasonPack, errors.Wrap(fmt.Errorf("%s", classification.String()),
+			domain.StatusNotASeasonPack.String())
+	}
+
+	return domain.StatusSuccessfulMatch, nil
+}
+
+// getEpisodeMatches maps every valid episode file of a client torrent to its path in the announced season pack,
+// so torrents that bundle multiple episodes contribute all of them. The episode of a file is parsed from its
+// name and only falls back to the torrent name if the torrent contains a single episode file.
+func (p *processor) getEpisodeMatches(torrentFiles qbittorrent.TorrentFiles, torrentName, savePath string,
+	announcedPackDir string, episodeExtensions []string,
+) []matchInfo {
+	var epFiles []int
+	epsPerDir := make(map[string]int)
+
+	for i, f := range torrentFiles {
+		if !release.IsValidEpisodeFile(f.Name, episodeExtensions) || f.Size == 0 {
+			continue
+		}
+
+		epFiles = append(epFiles, i)
+		epsPerDir[filepath.Dir(f.Name)]++
+	}
+
+	var matches []matchInfo
+
+	for _, i := range epFiles {
+		f := torrentFiles[i]
+
+		if f.Progress < 1 {
+			p.log.Debug().Msgf("skipping incomplete episode file: %s (%.2f%%)", f.Name, f.Progress*100)
+			continue
+		}
+
+		epRls := rls.ParseString(filepath.Base(f.Name))
+		if epRls.Episode == 0 && len(epFiles) == 1 {
+			epRls = rls.ParseString(torrentName)
+		}
+
+		announcedEpPath, err := utils.SafeJoin(announcedPackDir, filepath.Base(f.Name))
+		if err != nil {
+			p.log.Error().Err(err).Msgf("skipping episode file: %s", f.Name)
+			continue
+		}
+
+		matches = append(matches, matchInfo{
+			clientEpPath:    filepath.Join(savePath, f.Name),
+			clientEpSize:    f.Size,
+			announcedEpPath: announcedEpPath,
+			episode:         episodeKey{season: epRls.Series, episode: epRls.Episode},
+		})
+
+		if p.cfg.Config.CompanionFiles.Enabled {
+			matches = append(matches, p.getCompanionMatches(torrentFiles, savePath, f.Name, announcedPackDir,
+				episodeExtensions, epsPerDir[filepath.Dir(f.Name)] > 1)...)
+		}
+	}
+
+	return matches
+}
+
+// dedupeMatches removes episodes that were found in more than one client torrent, keeping the first one, along
+// with the companion files of the removed episodes. Episodes that couldn't be parsed are deduped by their target.
+func dedupeMatches(matches []matchInfo) []matchInfo {
+	seenEps := make(map[episodeKey]struct{})
+	seenTargets := make(map[string]struct{})
+	keptEps := make(map[string]struct{})
+
+	deduped := make([]matchInfo, 0, len(matches))
+
+	for _, match := range matches {
+		if len(match.companionOf) != 0 {
+			continue
+		}
+
+		if match.episode.episode != 0 {
+			if _, ok := seenEps[match.episode]; ok {
+				continue
+			}
+			seenEps[match.episode] = struct{}{}
+		}
+
+		if _, ok := seenTargets[match.announcedEpPath]; ok {
+			continue
+		}
+		seenTargets[match.announcedEpPath] = struct{}{}
+		keptEps[match.clientEpPath] = struct{}{}
+
+		deduped = append(deduped, match)
+	}
+
+	for _, match := range matches {
+		if len(match.companionOf) == 0 {
+			continue
+		}
+
+		if _, ok := keptEps[match.companionOf]; !ok {
+			continue
+		}
+
+		if _, ok := seenTargets[match.announcedEpPath]; ok {
+			continue
+		}
+		seenTargets[match.announcedEpPath] = struct{}{}
+
+		deduped = append(deduped, match)
+	}
+
+	return deduped
 }
 
-func (p *processor) getFiles(hash string) (*qbittorrent.TorrentFiles, error) {
-	return p.req.Client.GetFilesInformation(hash)
+// countEpisodes returns the number of distinct episodes of the matches. Episodes that couldn't be parsed are left
+// out, they can't be told apart and would count as a single episode 0.
+func countEpisodes(matches []matchInfo) int {
+	eps := make(map[episodeKey]struct{})
+	for _, match := range matches {
+		if len(match.companionOf) != 0 || match.episode.episode == 0 {
+			continue
+		}
+
+		eps[match.episode] = struct{}{}
+	}
+
+	return len(eps)
+}
+
+// getCompanionMatches collects the companion files, e.g. subtitles and NFOs, that are stored next to or below
+// the episode file of a client torrent. Without the torrent file layout, companions in the episode folder are
+// placed next to the episode and companions in subfolders into a folder named after the episode, e.g.
+// Subs/<episode>/English.srt, which is what most season packs use. If the folder is shared with other episodes,
+// only companions that contain the episode name in their path are collected.
+func (p *processor) getCompanionMatches(torrentFiles qbittorrent.TorrentFiles, savePath, epFileName string,
+	announcedPackDir string, episodeExtensions []string, sharedDir bool,
+) []matchInfo {
+	epDir := filepath.Dir(epFileName)
+	epName := strings.TrimSuffix(filepath.Base(epFileName), filepath.Ext(epFileName))
+	clientEpPath := filepath.Join(savePath, epFileName)
+
+	var matches []matchInfo
+
+	for _, f := range torrentFiles {
+		relPath, err := filepath.Rel(epDir, f.Name)
+		if err != nil || strings.HasPrefix(relPath, "..") {
+			continue
+		}
+
+		if !release.IsCompanionFile(relPath, p.cfg.Config.CompanionFiles, episodeExtensions) || f.Progress < 1 {
+			continue
+		}
+
+		hasEpName := strings.Contains(strings.ToLower(relPath), strings.ToLower(epName))
+		if sharedDir && !hasEpName {
+			continue
+		}
+
+		announcedPath, err := utils.SafeJoin(announcedPackDir, relPath)
+		if relDir := filepath.Dir(relPath); relDir != "." && !hasEpName {
+			announcedPath, err = utils.SafeJoin(announcedPackDir, relDir, epName, filepath.Base(relPath))
+		}
+		if err != nil {
+			p.log.Error().Err(err).Msgf("skipping companion file: %s", f.Name)
+			continue
+		}
+
+		matches = append(matches, matchInfo{
+			clientEpPath:    filepath.Join(savePath, f.Name),
+			clientEpSize:    f.Size,
+			announcedEpPath: announcedPath,
+			companionOf:     clientEpPath,
+		})
+
+		p.log.Debug().Msgf("found companion file in client: name(%s), size(%d)", f.Name, f.Size)
+	}
+
+	return matches
 }
 
 func (p *processor) getClientName() string {
@@ -170,7 +706,7 @@ func (p *processor) ProcessSeasonPackHandler(c *gin.Context) {
 		return
 	}
 
-	statusCode, err := p.processSeasonPack()
+	statusCode, err := p.processSeasonPack(c.Request.Context())
 	if err != nil {
 		go func() {
 			if sendErr := p.noti.Send(statusCode, domain.NotificationPayload{
@@ -202,10 +738,10 @@ func (p *processor) ProcessSeasonPackHandler(c *gin.Context) {
 	}()
 
 	p.log.Info().Msg("successfully matched season pack to episodes in client")
-	c.String(statusCode.Code(), statusCode.String())
+	p.respond(c, statusCode)
 }
 
-func (p *processor) processSeasonPack() (domain.StatusCode, error) {
+func (p *processor) processSeasonPack(ctx context.Context) (domain.StatusCode, error) {
 	clientName := p.getClientName()
 
 	p.log.UpdateContext(func(c zerolog.Context) zerolog.Context {
@@ -222,43 +758,69 @@ func (p *processor) processSeasonPack() (domain.StatusCode, error) {
 		return domain.StatusAnnounceNameError, domain.StatusAnnounceNameError.Error()
 	}
 
+	episodeExtensions := p.getEpisodeExtensions(clientCfg)
+
+	requestRls := rls.ParseString(p.req.Name)
+	if statusCode, err := p.classifySeasonPack(requestRls, p.getSeasonPackHints(ctx, episodeExtensions)); err != nil {
+		return statusCode, err
+	}
+
 	if err := p.getClient(clientCfg, clientName); err != nil {
 		return domain.StatusGetClientError, errors.Wrap(err, domain.StatusGetClientError.String())
 	}
 
-	tre := p.getAllTorrents(clientName)
+	tre := p.getAllTorrents(ctx, clientCfg, clientName)
 	if tre.err != nil {
 		return domain.StatusGetTorrentsError, errors.Wrap(tre.err, domain.StatusGetTorrentsError.String())
 	}
 
-	requestRls := rls.ParseString(p.req.Name)
-	clientEntries, ok := tre.entriesMap[utils.GetFormattedTitle(requestRls)]
-	if !ok {
+	fuzzyMatching := p.cfg.FuzzyMatching()
+	fmtTitle := utils.GetFormattedTitle(requestRls, p.cfg.Config.SeparateLanguages)
+
+	clientEntries := tre.entriesMap[fmtTitle]
+	if statusCode, err := p.checkAlreadyInClient(ctx, requestRls, fuzzyMatching, clientCfg.Filters,
+		slices.Concat(clientEntries, tre.excludedMap[fmtTitle]), tre.excludedMap != nil); err != nil {
+		return statusCode, err
+	}
+
+	if len(clientEntries) == 0 {
 		return domain.StatusNoMatches, domain.StatusNoMatches.Error()
 	}
 
-	announcedPackName := utils.FormatSeasonPackTitle(p.req.Name)
+	folderNamer, err := p.cfg.FolderNamer(clientName)
+	if err != nil {
+		return domain.StatusFolderNameError, errors.Wrap(err, domain.StatusFolderNameError.String())
+	}
+
+	announcedPackName, err := folderNamer.Format(p.req.Name)
+	if err != nil {
+		return domain.StatusFolderNameError, errors.Wrap(err, domain.StatusFolderNameError.String())
+	}
 	p.log.Debug().Msgf("formatted season pack name: %s", announcedPackName)
 
-	for _, clientEntry := range clientEntries {
-		switch compareInfo := release.CheckCandidates(requestRls, clientEntry.r, p.cfg.Config.FuzzyMatching); compareInfo.StatusCode {
-		case domain.StatusAlreadyInClient, domain.StatusNotASeasonPack:
-			return compareInfo.StatusCode, compareInfo.StatusCode.Error()
-		}
+	announcedPackDir, err := utils.PackTargetPath(clientCfg.PreImportPath, announcedPackName, "")
+	if err != nil {
+		return domain.StatusUnsafePath, errors.Wrap(err, domain.StatusUnsafePath.String())
 	}
 
 	codeSet := make(map[domain.StatusCode]bool)
-	epsSet := make(map[int]struct{})
 	matches := make([]matchInfo, 0, len(clientEntries))
 
+	// incomplete torrents share a single deadline, so a request never waits longer than the incomplete timeout, and
+	// stop waiting once the caller went away
+	waitCtx, cancel := context.WithTimeout(ctx,
+		time.Duration(p.cfg.Config.IncompleteTimeout)*time.Second)
+	defer cancel()
+
 	for _, clientEntry := range clientEntries {
-		switch compareInfo := release.CheckCandidates(requestRls, clientEntry.r, p.cfg.Config.FuzzyMatching); compareInfo.StatusCode {
-		case domain.StatusAlreadyInClient, domain.StatusNotASeasonPack:
+		switch compareInfo := release.CheckCandidates(requestRls, clientEntry.r, fuzzyMatching); compareInfo.StatusCode {
+		case domain.StatusAlreadyInClient:
 			return compareInfo.StatusCode, compareInfo.StatusCode.Error()
 
 		case domain.StatusResolutionMismatch, domain.StatusSourceMismatch, domain.StatusRlsGrpMismatch,
 			domain.StatusCutMismatch, domain.StatusEditionMismatch, domain.StatusRepackStatusMismatch,
-			domain.StatusHdrMismatch, domain.StatusStreamingServiceMismatch:
+			domain.StatusHdrMismatch, domain.StatusStreamingServiceMismatch, domain.StatusAudioMismatch,
+			domain.StatusChannelsMismatch, domain.StatusCodecMismatch, domain.StatusLanguageMismatch:
 			p.log.Info().Msgf("%s: request(%s => %v), client(%s => %v)",
 				compareInfo.StatusCode, requestRls.String(), compareInfo.RejectValueA,
 				clientEntry.r.String(), compareInfo.RejectValueB)
@@ -266,54 +828,60 @@ func (p *processor) processSeasonPack() (domain.StatusCode, error) {
 			continue
 
 		case domain.StatusSuccessfulMatch:
-			torrentFiles, err := p.getFiles(clientEntry.t.Hash)
+			if !isTorrentComplete(clientEntry.t) {
+				t, err := p.awaitCompletion(waitCtx, clientEntry.t)
+				if err != nil {
+					p.log.Info().Err(err).Msgf("%s: %s (%.2f%%, %s)", domain.StatusIncompleteTorrent,
+						clientEntry.t.Name, clientEntry.t.Progress*100, clientEntry.t.State)
+					codeSet[domain.StatusIncompleteTorrent] = true
+					continue
+				}
+				clientEntry.t = t
+			}
+
+			torrentFiles, err := p.getFiles(clientName, clientEntry.t)
 			if err != nil {
 				p.log.Error().Err(err).Msgf("error getting files: %s", clientEntry.t.Name)
 				continue
 			}
 
-			var fileName string
-			var size int64
-			for _, f := range *torrentFiles {
-				if !release.IsValidEpisodeFile(f.Name) {
-					continue
-				}
+			savePath := utils.MapRemotePath(clientEntry.t.SavePath, clientCfg.PathMappings)
 
-				fileName = f.Name
-				size = f.Size
-				break
-			}
-			if len(fileName) == 0 || size == 0 {
-				p.log.Error().Err(err).Msgf("error getting filename or size: %s", clientEntry.t.Name)
+			clientMatches := p.getEpisodeMatches(*torrentFiles, clientEntry.t.Name, savePath,
+				announcedPackDir, episodeExtensions)
+			if len(clientMatches) == 0 {
+				p.log.Error().Msgf("error getting episode files: %s", clientEntry.t.Name)
 				continue
 			}
 
-			epRls := rls.ParseString(clientEntry.t.Name)
-			clientEpPath := filepath.Join(clientEntry.t.SavePath, fileName)
-			announcedEpPath := filepath.Join(clientCfg.PreImportPath, announcedPackName, filepath.Base(fileName))
+			var epCount int
+			for _, match := range clientMatches {
+				if len(match.companionOf) != 0 {
+					continue
+				}
 
-			epsSet[epRls.Episode] = struct{}{}
+				epCount++
+			}
 
-			// append current matchInfo to matches slice
-			matches = append(matches, matchInfo{
-				clientEpPath:    clientEpPath,
-				clientEpSize:    size,
-				announcedEpPath: announcedEpPath,
-			})
+			// append matchInfo of all episode files to matches slice
+			matches = append(matches, clientMatches...)
 
-			p.log.Debug().Msgf("matched torrent from client: name(%s), size(%d), hash(%s)",
-				clientEntry.t.Name, size, clientEntry.t.Hash)
+			p.log.Debug().Msgf("matched torrent from client: name(%s), episodes(%d), hash(%s)",
+				clientEntry.t.Name, epCount, clientEntry.t.Hash)
 			codeSet[compareInfo.StatusCode] = true
 			continue
 		}
 	}
 
 	if !codeSet[domain.StatusSuccessfulMatch] {
+		if codeSet[domain.StatusIncompleteTorrent] {
+			return domain.StatusIncompleteTorrent, domain.StatusIncompleteTorrent.Error()
+		}
 		return domain.StatusNoMatches, domain.StatusNoMatches.Error()
 	}
 
 	// dedupe matches and store in matchesMap
-	matches = utils.DedupeSlice(matches)
+	matches = dedupeMatches(matches)
 	matchesMap.Store(p.req.Name, matches)
 
 	if p.cfg.Config.SmartMode {
@@ -322,7 +890,7 @@ func (p *processor) processSeasonPack() (domain.StatusCode, error) {
 			return domain.StatusEpisodeCountError, errors.Wrap(err, domain.StatusEpisodeCountError.String())
 		}
 
-		foundEps := len(epsSet)
+		foundEps := countEpisodes(matches)
 		percentEps := release.PercentOfTotalEpisodes(totalEps, foundEps)
 
 		if percentEps < p.cfg.Config.SmartModeThreshold {
@@ -339,18 +907,27 @@ func (p *processor) processSeasonPack() (domain.StatusCode, error) {
 	}
 
 	successfulHardlink := false
+	packDirExisted := dirExists(announcedPackDir)
 
 	for _, match := range matches {
-		if err := utils.CreateHardlink(match.clientEpPath, match.announcedEpPath); err != nil {
-			p.log.Error().Err(err).Msgf("error creating hardlink: %s", match.clientEpPath)
+		if err := p.createLink(clientCfg, match.clientEpPath, match.announcedEpPath); err != nil {
+			p.log.Error().Err(err).Msgf("error creating link: %s", match.clientEpPath)
 			continue
 		}
-		p.log.Log().Msgf("created hardlink: source(%s), target(%s)", match.clientEpPath, match.announcedEpPath)
-		successfulHardlink = true
+
+		// companion files alone don't make a successful season pack
+		if len(match.companionOf) == 0 {
+			successfulHardlink = true
+		}
+	}
+
+	if !packDirExisted {
+		p.trackFolder(clientName, announcedPackDir)
 	}
 
 	if !successfulHardlink {
-		return domain.StatusFailedHardlink, domain.StatusFailedHardlink.Error()
+		statusCode := p.getFailedLinkStatus()
+		return statusCode, statusCode.Error()
 	}
 
 	return domain.StatusSuccessfulHardlink, nil
@@ -359,7 +936,7 @@ func (p *processor) processSeasonPack() (domain.StatusCode, error) {
 func (p *processor) ParseTorrentHandler(c *gin.Context) {
 	p.log.Info().Msg("starting to parse season pack torrent")
 
-	if err := json.NewDecoder(c.Request.Body).Decode(&p.req); err != nil {
+	if err := p.decodeRequest(c); err != nil {
 		p.log.Error().Err(err).Msgf("%s", domain.StatusDecodingError)
 		c.AbortWithStatusJSON(domain.StatusDecodingError.Code(), gin.H{
 			"statusCode": domain.StatusDecodingError.Code(),
@@ -368,7 +945,7 @@ func (p *processor) ParseTorrentHandler(c *gin.Context) {
 		return
 	}
 
-	statusCode, err := p.parseTorrent()
+	statusCode, err := p.parseTorrent(c.Request.Context())
 	if err != nil {
 		go func() {
 			if sendErr := p.noti.Send(statusCode, domain.NotificationPayload{
@@ -400,10 +977,44 @@ func (p *processor) ParseTorrentHandler(c *gin.Context) {
 	}()
 
 	p.log.Info().Msg("successfully parsed torrent and hardlinked episodes")
-	c.String(statusCode.Code(), statusCode.String())
+	p.respond(c, statusCode)
 }
 
-func (p *processor) parseTorrent() (domain.StatusCode, error) {
+// respond writes the status of a successful request. The created links, the readiness and the infohash and recheck
+// state of the injected season pack are only reported as JSON if the caller accepts it and there are any, otherwise
+// the plain status message is returned like before.
+func (p *processor) respond(c *gin.Context, statusCode domain.StatusCode) {
+	if c.NegotiateFormat(gin.MIMEPlain, gin.MIMEJSON) != gin.MIMEJSON ||
+		p.readiness == nil && len(p.links) == 0 && len(p.injected) == 0 {
+		c.String(statusCode.Code(), statusCode.String())
+		return
+	}
+
+	body := gin.H{
+		"statusCode": statusCode.Code(),
+		"message":    statusCode.String(),
+	}
+
+	if len(p.links) != 0 {
+		body["links"] = p.links
+	}
+
+	if p.readiness != nil {
+		body["readiness"] = p.readiness
+	}
+
+	if len(p.injected) != 0 {
+		body["injected"] = p.injected
+	}
+
+	if p.recheck != nil {
+		body["recheck"] = p.recheck
+	}
+
+	c.JSON(statusCode.Code(), body)
+}
+
+func (p *processor) parseTorrent(ctx context.Context) (domain.StatusCode, error) {
 	clientName := p.getClientName()
 
 	p.log.UpdateContext(func(c zerolog.Context) zerolog.Context {
@@ -419,24 +1030,38 @@ func (p *processor) parseTorrent() (domain.StatusCode, error) {
 		return domain.StatusAnnounceNameError, domain.StatusAnnounceNameError.Error()
 	}
 
-	if len(p.req.Torrent) == 0 {
-		return domain.StatusTorrentBytesError, domain.StatusTorrentBytesError.Error()
+	pack, statusCode, err := p.getSeasonPack(ctx, clientCfg, clientName)
+	if err != nil {
+		return statusCode, err
 	}
+	p.log.Debug().Msgf("parsed season pack name: %s", pack.name)
 
-	torrentBytes, err := torrents.DecodeTorrentBytes(p.req.Torrent)
+	targetPackDir, err := getTargetPackDir(clientCfg.PreImportPath, pack)
 	if err != nil {
-		return domain.StatusDecodeTorrentBytesError, errors.Wrap(err, domain.StatusDecodeTorrentBytesError.String())
+		return domain.StatusUnsafePath, errors.Wrap(err, domain.StatusUnsafePath.String())
 	}
-	p.req.Torrent = torrentBytes
 
-	torrentInfo, err := torrents.ParseInfoFromTorrentBytes(p.req.Torrent)
-	if err != nil {
-		return domain.StatusParseTorrentInfoError, errors.Wrap(err, domain.StatusParseTorrentInfoError.String())
+	episodeExtensions := p.getEpisodeExtensions(clientCfg)
+
+	filePaths := make([]string, 0, len(pack.files))
+	for _, file := range pack.files {
+		filePaths = append(filePaths, file.Path)
+	}
+
+	if statusCode, err := p.classifySeasonPack(rls.ParseString(p.req.Name), release.SeasonPackHints{
+		Category:          p.req.Category,
+		FilePaths:         filePaths,
+		EpisodeExtensions: episodeExtensions,
+	}); err != nil {
+		return statusCode, err
 	}
-	parsedPackName := torrentInfo.BestName()
-	p.log.Debug().Msgf("parsed season pack name: %s", parsedPackName)
 
-	torrentEps, err := torrents.GetEpisodesFromTorrentInfo(torrentInfo)
+	if !pack.isDir {
+		return domain.StatusGetEpisodesError, errors.Wrap(fmt.Errorf("not a directory"),
+			domain.StatusGetEpisodesError.String())
+	}
+
+	torrentEps, err := torrents.GetEpisodes(pack.files, episodeExtensions)
 	if err != nil {
 		return domain.StatusGetEpisodesError, errors.Wrap(err, domain.StatusGetEpisodesError.String())
 	}
@@ -449,34 +1074,38 @@ func (p *processor) parseTorrent() (domain.StatusCode, error) {
 		return domain.StatusNoMatches, domain.StatusNoMatches.Error()
 	}
 
-	successfulEpMatch := false
-	successfulHardlink := false
-
 	var matchedEpPath string
 	var compareInfo domain.CompareInfo
 
-	targetPackDir := filepath.Join(clientCfg.PreImportPath, parsedPackName)
+	groupAliases := p.cfg.FuzzyMatching().GroupAliases
+
+	// pieces are only known if the torrent file was part of the request
+	verifyPieces := p.cfg.Config.VerifyPieces.Enabled && pack.info != nil
+	if p.cfg.Config.VerifyPieces.Enabled && pack.info == nil {
+		p.log.Debug().Msg("no pieces available, skipping piece verification")
+	}
+
+	links := make([]linkInfo, 0, len(matches))
+	matchedClientEps := make(map[string]string)
 
 	for _, match := range matches {
-		for _, torrentEp := range torrentEps {
-			var targetEpPath string
+		if len(match.companionOf) != 0 {
+			continue
+		}
 
+		for _, torrentEp := range torrentEps {
 			matchedEpPath, compareInfo = release.MatchEpToSeasonPackEp(match.clientEpPath, match.clientEpSize,
-				torrentEp.Path, torrentEp.Size)
+				torrentEp.Path, torrentEp.Size, groupAliases)
 			if len(matchedEpPath) == 0 {
 				p.log.Debug().Msgf("%s: client(%s => %v), torrent(%s => %v)", compareInfo.StatusCode,
 					filepath.Base(match.clientEpPath), compareInfo.RejectValueA, torrentEp.Path, compareInfo.RejectValueB)
 				continue
 			}
-			targetEpPath = filepath.Join(targetPackDir, matchedEpPath)
-			successfulEpMatch = true
 
-			if err = utils.CreateHardlink(match.clientEpPath, targetEpPath); err != nil {
-				p.log.Error().Err(err).Msgf("error creating hardlink: %s", match.clientEpPath)
+			if verifyPieces && !p.verifyPieces(*pack.info, torrentEp.Path, match.clientEpPath) {
+				matchedEpPath = ""
 				continue
 			}
-			p.log.Log().Msgf("created hardlink: source(%s), target(%s)", match.clientEpPath, targetEpPath)
-			successfulHardlink = true
 
 			break
 		}
@@ -485,15 +1114,189 @@ func (p *processor) parseTorrent() (domain.StatusCode, error) {
 				filepath.Base(match.clientEpPath))
 			continue
 		}
+
+		links = append(links, linkInfo{clientPath: match.clientEpPath, torrentPath: matchedEpPath})
+		matchedClientEps[match.clientEpPath] = matchedEpPath
 	}
 
-	if !successfulEpMatch {
+	if len(links) == 0 {
 		return domain.StatusFailedMatchToTorrentEps, domain.StatusFailedMatchToTorrentEps.Error()
 	}
 
-	if !successfulHardlink {
-		return domain.StatusFailedHardlink, domain.StatusFailedHardlink.Error()
+	if p.cfg.Config.CompanionFiles.Enabled {
+		torrentCompanions := torrents.GetCompanionFiles(pack.files, func(filePath string) bool {
+			return release.IsCompanionFile(filePath, p.cfg.Config.CompanionFiles, episodeExtensions)
+		})
+		links = append(links, p.matchCompanionFiles(torrentCompanions, matches, matchedClientEps)...)
+	}
+
+	if threshold := p.cfg.Config.CompletionThreshold; threshold > 0 && pack.info != nil {
+		readiness, err := p.getReadiness(*pack.info, links)
+		if err != nil {
+			p.log.Error().Err(err).Msg("error calculating completion, skipping completion threshold")
+		} else if readiness.Completion < threshold {
+			return domain.StatusBelowCompletion, errors.Wrap(fmt.Errorf("%d/%d pieces (%.2f%%) complete after recheck",
+				readiness.CompletePieces, readiness.TotalPieces, readiness.Completion*100),
+				domain.StatusBelowCompletion.String())
+		}
+	}
+
+	linked := make([]linkInfo, 0, len(links))
+	packDirExisted := dirExists(targetPackDir)
+
+	for _, link := range links {
+		targetPath, err := utils.SafeJoin(targetPackDir, link.torrentPath)
+		if err != nil {
+			p.log.Error().Err(err).Msgf("error creating link: %s", link.clientPath)
+			continue
+		}
+
+		if err = p.createLink(clientCfg, link.clientPath, targetPath); err != nil {
+			p.log.Error().Err(err).Msgf("error creating link: %s", link.clientPath)
+			continue
+		}
+
+		linked = append(linked, link)
+	}
+
+	if !packDirExisted {
+		p.trackFolder(clientName, targetPackDir)
+	}
+
+	// companion files alone don't make a successful season pack
+	if !slices.ContainsFunc(linked, func(link linkInfo) bool { return !link.companion }) {
+		statusCode := p.getFailedLinkStatus()
+		return statusCode, statusCode.Error()
+	}
+
+	if pack.info != nil {
+		if readiness, err := p.getReadiness(*pack.info, linked); err != nil {
+			p.log.Error().Err(err).Msg("error calculating completion")
+		} else {
+			p.readiness = &readiness
+		}
+	}
+
+	if clientCfg.Inject.Enabled {
+		if err = p.injectSeasonPack(ctx, clientCfg, clientName, pack); err != nil {
+			return domain.StatusInjectError, errors.Wrap(err, domain.StatusInjectError.String())
+		}
+	}
+
+	// injected packs are watched by injectSeasonPack already
+	if len(pack.hash) != 0 && len(p.injected) == 0 && p.cfg.Config.Recheck.Enabled {
+		trackPreparedPack(clientName, pack.hash, p.req.Name)
 	}
 
 	return domain.StatusSuccessfulHardlink, nil
 }
+
+// getReadiness calculates how much of the season pack will be complete after the client rechecks the given links.
+func (p *processor) getReadiness(torrentInfo metainfo.Info, links []linkInfo) (torrents.Readiness, error) {
+	torrentPaths := make([]string, 0, len(links))
+	for _, link := range links {
+		torrentPaths = append(torrentPaths, link.torrentPath)
+	}
+
+	readiness, err := torrents.GetReadiness(torrentInfo, torrentPaths)
+	if err != nil {
+		return torrents.Readiness{}, err
+	}
+
+	p.log.Debug().Msgf("%d/%d pieces (%.2f%%) complete after recheck", readiness.CompletePieces,
+		readiness.TotalPieces, readiness.Completion*100)
+
+	return readiness, nil
+}
+
+// verifyPieces hashes the pieces of the client episode against the pieces of the matched file in the season pack,
+// so files that only match by name and size aren't linked and later fail the recheck in the client.
+func (p *processor) verifyPieces(torrentInfo metainfo.Info, torrentEpPath, clientEpPath string) bool {
+	samples := p.cfg.Config.VerifyPieces.Samples
+	if p.cfg.Config.VerifyPieces.Mode == domain.VerifyPiecesModeFull {
+		samples = 0
+	}
+
+	verified, err := torrents.VerifyFilePieces(torrentInfo, torrentEpPath, clientEpPath, samples)
+	if err != nil {
+		if errors.Is(err, torrents.ErrPieceMismatch) {
+			p.log.Info().Err(err).Msgf("%s: client(%s), torrent(%s)", domain.StatusPieceMismatch,
+				filepath.Base(clientEpPath), torrentEpPath)
+			return false
+		}
+
+		// nothing was verified, so the file is treated like one that failed verification
+		if errors.Is(err, torrents.ErrNoVerifiablePieces) {
+			p.log.Info().Err(err).Msgf("skipped verifying pieces, treating file as unverified: client(%s), torrent(%s)",
+				filepath.Base(clientEpPath), torrentEpPath)
+			return false
+		}
+
+		p.log.Error().Err(err).Msgf("error verifying pieces: %s", clientEpPath)
+		return false
+	}
+
+	p.log.Debug().Msgf("verified %d pieces: client(%s), torrent(%s)", verified, filepath.Base(clientEpPath),
+		torrentEpPath)
+
+	return true
+}
+
+// matchCompanionFiles matches the companion files of all matched episodes to the companion files of the
+// season pack. Failing to match a companion file is only logged, since the episodes are what's needed for
+// the cross-seed.
+func (p *processor) matchCompanionFiles(torrentCompanions []torrents.CompanionFile, matches []matchInfo,
+	matchedClientEps map[string]string,
+) []linkInfo {
+	if len(torrentCompanions) == 0 {
+		return nil
+	}
+
+	var links []linkInfo
+	usedCompanions := make(map[string]struct{})
+
+	for _, match := range matches {
+		if len(match.companionOf) == 0 {
+			continue
+		}
+
+		matchedEpPath, ok := matchedClientEps[match.companionOf]
+		if !ok {
+			continue
+		}
+
+		var matchedCompanionPath string
+		var compareInfo domain.CompareInfo
+
+		for _, torrentCompanion := range torrentCompanions {
+			if _, used := usedCompanions[torrentCompanion.Path]; used {
+				continue
+			}
+
+			matchedCompanionPath, compareInfo = release.MatchCompanionToSeasonPackFile(match.clientEpPath,
+				match.clientEpSize, match.companionOf, torrentCompanion.Path, torrentCompanion.Size)
+			if len(matchedCompanionPath) == 0 {
+				p.log.Trace().Msgf("%s: client(%s => %v), torrent(%s => %v)", compareInfo.StatusCode,
+					filepath.Base(match.clientEpPath), compareInfo.RejectValueA, torrentCompanion.Path,
+					compareInfo.RejectValueB)
+				continue
+			}
+			usedCompanions[torrentCompanion.Path] = struct{}{}
+
+			break
+		}
+		if len(matchedCompanionPath) == 0 {
+			p.log.Debug().Msgf("error matching companion file of %s to file in pack, skipping hardlink: %s",
+				matchedEpPath, filepath.Base(match.clientEpPath))
+			continue
+		}
+
+		links = append(links, linkInfo{
+			clientPath:  match.clientEpPath,
+			torrentPath: matchedCompanionPath,
+			companion:   true,
+		})
+	}
+
+	return links
+}
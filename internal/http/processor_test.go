@@ -0,0 +1,536 @@
+// Copyright (c) 2023 - 2024, nuxen and the seasonpackarr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/nuxencs/seasonpackarr/internal/config"
+	"github.com/nuxencs/seasonpackarr/internal/domain"
+	"github.com/nuxencs/seasonpackarr/internal/torrents"
+
+	"github.com/autobrr/go-qbittorrent"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestProcessor returns a processor without any clients, so requests stop right after they were decoded.
+func newTestProcessor() *processor {
+	return &processor{
+		log:  zerolog.Nop(),
+		cfg:  &config.AppConfig{Config: &domain.Config{Clients: map[string]*domain.Client{}}},
+		noti: &fakeSender{},
+	}
+}
+
+func Test_processor_ParseTorrentHandler_decodeRequest(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	const name = "Series.Title.S01.1080p.WEB-DL.DDP5.1.H.264-RlsGrp"
+	torrentBytes := []byte("d4:infod4:name4:packee")
+
+	multipartBody := func(withTorrent bool) (string, io.Reader) {
+		var body bytes.Buffer
+		w := multipart.NewWriter(&body)
+		_ = w.WriteField("name", name)
+		_ = w.WriteField("clientname", "missing")
+		_ = w.WriteField("infohash", "0123456789abcdef0123456789abcdef01234567")
+		if withTorrent {
+			fw, _ := w.CreateFormFile("torrent", name+".torrent")
+			_, _ = fw.Write(torrentBytes)
+		}
+		_ = w.Close()
+
+		return w.FormDataContentType(), &body
+	}
+
+	tests := []struct {
+		name        string
+		newRequest  func() *http.Request
+		wantStatus  domain.StatusCode
+		wantRequest request
+	}{
+		{
+			name: "json",
+			newRequest: func() *http.Request {
+				body, _ := json.Marshal(map[string]string{
+					"name":       name,
+					"torrentUrl": "https://tracker.example/pack.torrent",
+					"clientname": "missing",
+				})
+				req := httptest.NewRequest(http.MethodPost, "/api/parse", bytes.NewReader(body))
+				req.Header.Set("Content-Type", "application/json")
+				return req
+			},
+			wantStatus: domain.StatusClientNotFound,
+			wantRequest: request{
+				Name:       name,
+				TorrentURL: "https://tracker.example/pack.torrent",
+				ClientName: "missing",
+			},
+		},
+		{
+			name: "multipart",
+			newRequest: func() *http.Request {
+				contentType, body := multipartBody(true)
+				req := httptest.NewRequest(http.MethodPost, "/api/parse", body)
+				req.Header.Set("Content-Type", contentType)
+				return req
+			},
+			wantStatus: domain.StatusClientNotFound,
+			wantRequest: request{
+				Name:         name,
+				InfoHash:     "0123456789abcdef0123456789abcdef01234567",
+				ClientName:   "missing",
+				torrentBytes: torrentBytes,
+			},
+		},
+		{
+			name: "multipart_without_torrent",
+			newRequest: func() *http.Request {
+				contentType, body := multipartBody(false)
+				req := httptest.NewRequest(http.MethodPost, "/api/parse", body)
+				req.Header.Set("Content-Type", contentType)
+				return req
+			},
+			wantStatus: domain.StatusClientNotFound,
+			wantRequest: request{
+				Name:       name,
+				InfoHash:   "0123456789abcdef0123456789abcdef01234567",
+				ClientName: "missing",
+			},
+		},
+		{
+			name: "bittorrent",
+			newRequest: func() *http.Request {
+				req := httptest.NewRequest(http.MethodPost, "/api/parse?name="+name+"&clientname=missing",
+					bytes.NewReader(torrentBytes))
+				req.Header.Set("Content-Type", "application/x-bittorrent")
+				return req
+			},
+			wantStatus: domain.StatusClientNotFound,
+			wantRequest: request{
+				Name:         name,
+				ClientName:   "missing",
+				torrentBytes: torrentBytes,
+			},
+		},
+		{
+			name: "bittorrent_too_large",
+			newRequest: func() *http.Request {
+				req := httptest.NewRequest(http.MethodPost, "/api/parse?name="+name+"&clientname=missing",
+					bytes.NewReader(make([]byte, torrents.MaxTorrentSize+1)))
+				req.Header.Set("Content-Type", "application/x-bittorrent")
+				return req
+			},
+			wantStatus: domain.StatusDecodingError,
+		},
+		{
+			name: "invalid_json",
+			newRequest: func() *http.Request {
+				req := httptest.NewRequest(http.MethodPost, "/api/parse", bytes.NewReader([]byte("{")))
+				req.Header.Set("Content-Type", "application/json")
+				return req
+			},
+			wantStatus: domain.StatusDecodingError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = tt.newRequest()
+
+			p := newTestProcessor()
+			p.ParseTorrentHandler(c)
+
+			var body struct {
+				StatusCode int `json:"statusCode"`
+			}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+			assert.Equal(t, tt.wantStatus.Code(), body.StatusCode)
+
+			if tt.wantStatus == domain.StatusDecodingError {
+				return
+			}
+
+			require.NotNil(t, p.req)
+			assert.Equal(t, tt.wantRequest, *p.req)
+		})
+	}
+}
+
+func Test_processor_ProcessSeasonPackHandler_decodeRequest(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// uploads are only supported by the parse endpoint, the pack endpoint only accepts JSON
+	req := httptest.NewRequest(http.MethodPost, "/api/pack?name=Series.Title.S01&clientname=missing",
+		bytes.NewReader([]byte("d4:infod4:name4:packee")))
+	req.Header.Set("Content-Type", "application/x-bittorrent")
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+
+	newTestProcessor().ProcessSeasonPackHandler(c)
+
+	var body struct {
+		StatusCode int `json:"statusCode"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, domain.StatusDecodingError.Code(), body.StatusCode)
+}
+
+func Test_processor_processSeasonPack(t *testing.T) {
+	const (
+		packName = "Series.Title.S01.1080p.WEB-DL.DDP5.1.H.264-RlsGrp"
+		packHash = "0123456789abcdef0123456789abcdef01234567"
+	)
+
+	tests := []struct {
+		name             string
+		filters          domain.TorrentFilters
+		infoHash         string
+		incompletePolicy string
+		// requestTimeout cancels the request context, zero keeps it open
+		requestTimeout time.Duration
+		torrents       []qbittorrent.Torrent
+		files          string
+		wantStatus     domain.StatusCode
+	}{
+		{
+			name:    "already_in_client",
+			filters: domain.TorrentFilters{Categories: []string{"tv"}},
+			torrents: []qbittorrent.Torrent{
+				{Hash: "a", Name: "Series.Title.S01E01.1080p.WEB-DL.DDP5.1.H.264-RlsGrp", Category: "tv", Progress: 1},
+				{Hash: packHash, Name: packName, Category: "tv", Progress: 1},
+			},
+			wantStatus: domain.StatusAlreadyInClient,
+		},
+		{
+			name:     "already_in_client_filtered",
+			filters:  domain.TorrentFilters{Categories: []string{"tv"}},
+			infoHash: packHash,
+			torrents: []qbittorrent.Torrent{
+				{Hash: "a", Name: "Series.Title.S01E01.1080p.WEB-DL.DDP5.1.H.264-RlsGrp", Category: "tv", Progress: 1},
+				// the pack itself was moved to another category, it is only found by its infohash
+				{Hash: packHash, Name: packName, Category: "archive", Progress: 1},
+			},
+			wantStatus: domain.StatusAlreadyInClient,
+		},
+		{
+			name:    "filtered",
+			filters: domain.TorrentFilters{Categories: []string{"tv"}},
+			torrents: []qbittorrent.Torrent{
+				{Hash: "a", Name: "Series.Title.S01E01.1080p.WEB-DL.DDP5.1.H.264-RlsGrp", Category: "movies", Progress: 1},
+			},
+			wantStatus: domain.StatusNoMatches,
+		},
+		{
+			name:             "incomplete_torrent",
+			incompletePolicy: domain.IncompletePolicySkip,
+			torrents: []qbittorrent.Torrent{
+				{Hash: "a", Name: "Series.Title.S01E01.1080p.WEB-DL.DDP5.1.H.264-RlsGrp", Progress: 0.5},
+			},
+			wantStatus: domain.StatusIncompleteTorrent,
+		},
+		{
+			name:             "incomplete_torrent_request_canceled",
+			incompletePolicy: domain.IncompletePolicyWait,
+			requestTimeout:   100 * time.Millisecond,
+			torrents: []qbittorrent.Torrent{
+				{Hash: "a", Name: "Series.Title.S01E01.1080p.WEB-DL.DDP5.1.H.264-RlsGrp", Progress: 0.5},
+			},
+			wantStatus: domain.StatusIncompleteTorrent,
+		},
+		{
+			name: "incomplete_file",
+			torrents: []qbittorrent.Torrent{
+				{Hash: "a", Name: "Series.Title.S01E01.1080p.WEB-DL.DDP5.1.H.264-RlsGrp", Progress: 1},
+			},
+			files:      `[{"name":"Series.Title.S01E01.1080p.WEB-DL.DDP5.1.H.264-RlsGrp.mkv","size":10,"progress":0.5}]`,
+			wantStatus: domain.StatusNoMatches,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clientName := "process_" + tt.name
+
+			fc, client := newFakeClient(t, tt.torrents)
+			fc.files = tt.files
+			clientMap.Store(clientName, client)
+			t.Cleanup(func() {
+				clientMap.Delete(clientName)
+				torrentMap.Delete(clientName)
+			})
+
+			p := newTestProcessor()
+			p.cfg.Config.Clients[clientName] = &domain.Client{PreImportPath: t.TempDir(), Filters: tt.filters}
+			p.cfg.Config.IncompletePolicy = tt.incompletePolicy
+			// the incomplete timeout outlasts the test, waiting only ends with the request
+			p.cfg.Config.IncompleteTimeout = 3600
+			p.req = &request{Name: packName, ClientName: clientName, InfoHash: tt.infoHash}
+
+			ctx := context.Background()
+			if tt.requestTimeout != 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.requestTimeout)
+				defer cancel()
+			}
+
+			statusCode, err := p.processSeasonPack(ctx)
+			assert.Error(t, err)
+			assert.Equal(t, tt.wantStatus, statusCode)
+		})
+	}
+}
+
+// newTorrentFiles builds the files of a client torrent, TorrentFiles is a slice of anonymous structs.
+func newTorrentFiles(t *testing.T, files string) qbittorrent.TorrentFiles {
+	t.Helper()
+
+	var torrentFiles qbittorrent.TorrentFiles
+	require.NoError(t, json.Unmarshal([]byte(files), &torrentFiles))
+
+	return torrentFiles
+}
+
+func Test_processor_getEpisodeMatches(t *testing.T) {
+	tests := []struct {
+		name        string
+		torrentName string
+		files       string
+		want        []matchInfo
+	}{
+		{
+			name:        "multi_episode_torrent",
+			torrentName: "Series.Title.S01.1080p.WEB-DL.DDP5.1.H.264-RlsGrp",
+			files: `[{"name":"Pack/Series.Title.S01E01.mkv","size":10,"progress":1},
+				{"name":"Pack/Series.Title.S01E02.mkv","size":20,"progress":1}]`,
+			want: []matchInfo{
+				{
+					clientEpPath:    "/data/Pack/Series.Title.S01E01.mkv",
+					clientEpSize:    10,
+					announcedEpPath: "/pre/Series.Title.S01E01.mkv",
+					episode:         episodeKey{season: 1, episode: 1},
+				},
+				{
+					clientEpPath:    "/data/Pack/Series.Title.S01E02.mkv",
+					clientEpSize:    20,
+					announcedEpPath: "/pre/Series.Title.S01E02.mkv",
+					episode:         episodeKey{season: 1, episode: 2},
+				},
+			},
+		},
+		{
+			name:        "incomplete_file",
+			torrentName: "Series.Title.S01.1080p.WEB-DL.DDP5.1.H.264-RlsGrp",
+			files: `[{"name":"Pack/Series.Title.S01E01.mkv","size":10,"progress":1},
+				{"name":"Pack/Series.Title.S01E02.mkv","size":20,"progress":0.5}]`,
+			want: []matchInfo{
+				{
+					clientEpPath:    "/data/Pack/Series.Title.S01E01.mkv",
+					clientEpSize:    10,
+					announcedEpPath: "/pre/Series.Title.S01E01.mkv",
+					episode:         episodeKey{season: 1, episode: 1},
+				},
+			},
+		},
+		{
+			name:        "unparsable_single_file",
+			torrentName: "Series.Title.S01E03.1080p.WEB-DL.DDP5.1.H.264-RlsGrp",
+			files:       `[{"name":"episode.mkv","size":10,"progress":1}]`,
+			want: []matchInfo{
+				{
+					clientEpPath:    "/data/episode.mkv",
+					clientEpSize:    10,
+					announcedEpPath: "/pre/episode.mkv",
+					episode:         episodeKey{season: 1, episode: 3},
+				},
+			},
+		},
+		{
+			name:        "unparsable_multiple_files",
+			torrentName: "Series.Title.S01.1080p.WEB-DL.DDP5.1.H.264-RlsGrp",
+			files: `[{"name":"Pack/first.mkv","size":10,"progress":1},
+				{"name":"Pack/second.mkv","size":20,"progress":1}]`,
+			want: []matchInfo{
+				{clientEpPath: "/data/Pack/first.mkv", clientEpSize: 10, announcedEpPath: "/pre/first.mkv"},
+				{clientEpPath: "/data/Pack/second.mkv", clientEpSize: 20, announcedEpPath: "/pre/second.mkv"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newTestProcessor()
+
+			got := p.getEpisodeMatches(newTorrentFiles(t, tt.files), tt.torrentName, "/data", "/pre",
+				[]string{".mkv"})
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_dedupeMatches(t *testing.T) {
+	tests := []struct {
+		name    string
+		matches []matchInfo
+		want    []matchInfo
+	}{
+		{
+			name: "across_torrents",
+			matches: []matchInfo{
+				{clientEpPath: "/a/E01.mkv", announcedEpPath: "/pre/E01.mkv", episode: episodeKey{1, 1}},
+				{clientEpPath: "/b/E01.mkv", announcedEpPath: "/pre/Other.E01.mkv", episode: episodeKey{1, 1}},
+				{clientEpPath: "/b/E02.mkv", announcedEpPath: "/pre/E02.mkv", episode: episodeKey{1, 2}},
+			},
+			want: []matchInfo{
+				{clientEpPath: "/a/E01.mkv", announcedEpPath: "/pre/E01.mkv", episode: episodeKey{1, 1}},
+				{clientEpPath: "/b/E02.mkv", announcedEpPath: "/pre/E02.mkv", episode: episodeKey{1, 2}},
+			},
+		},
+		{
+			name: "unparsable_by_target",
+			matches: []matchInfo{
+				{clientEpPath: "/a/first.mkv", announcedEpPath: "/pre/first.mkv"},
+				{clientEpPath: "/b/first.mkv", announcedEpPath: "/pre/first.mkv"},
+				{clientEpPath: "/b/second.mkv", announcedEpPath: "/pre/second.mkv"},
+			},
+			want: []matchInfo{
+				{clientEpPath: "/a/first.mkv", announcedEpPath: "/pre/first.mkv"},
+				{clientEpPath: "/b/second.mkv", announcedEpPath: "/pre/second.mkv"},
+			},
+		},
+		{
+			name: "companions_of_removed_episodes",
+			matches: []matchInfo{
+				{clientEpPath: "/a/E01.mkv", announcedEpPath: "/pre/E01.mkv", episode: episodeKey{1, 1}},
+				{clientEpPath: "/b/E01.mkv", announcedEpPath: "/pre/Other.E01.mkv", episode: episodeKey{1, 1}},
+				{clientEpPath: "/a/E01.srt", announcedEpPath: "/pre/E01.srt", companionOf: "/a/E01.mkv"},
+				{clientEpPath: "/b/E01.nfo", announcedEpPath: "/pre/Other.E01.nfo", companionOf: "/b/E01.mkv"},
+			},
+			want: []matchInfo{
+				{clientEpPath: "/a/E01.mkv", announcedEpPath: "/pre/E01.mkv", episode: episodeKey{1, 1}},
+				{clientEpPath: "/a/E01.srt", announcedEpPath: "/pre/E01.srt", companionOf: "/a/E01.mkv"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, dedupeMatches(tt.matches))
+		})
+	}
+}
+
+func Test_countEpisodes(t *testing.T) {
+	tests := []struct {
+		name    string
+		matches []matchInfo
+		want    int
+	}{
+		{
+			name: "distinct_episodes",
+			matches: []matchInfo{
+				{clientEpPath: "/a/E01.mkv", episode: episodeKey{1, 1}},
+				{clientEpPath: "/a/E02.mkv", episode: episodeKey{1, 2}},
+				{clientEpPath: "/a/E02.srt", companionOf: "/a/E02.mkv"},
+			},
+			want: 2,
+		},
+		{
+			name: "unparsable_episodes",
+			matches: []matchInfo{
+				{clientEpPath: "/a/E01.mkv", episode: episodeKey{1, 1}},
+				{clientEpPath: "/b/first.mkv"},
+				{clientEpPath: "/b/second.mkv"},
+			},
+			want: 1,
+		},
+		{
+			name: "only_unparsable_episodes",
+			matches: []matchInfo{
+				{clientEpPath: "/b/first.mkv"},
+			},
+			want: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, countEpisodes(tt.matches))
+		})
+	}
+}
+
+func Test_processor_respond(t *testing.T) {
+	links := []linkReport{{Source: "/data/E01.mkv", Target: "/pre/E01.mkv", Strategy: domain.LinkStrategyHardlink}}
+
+	tests := []struct {
+		name     string
+		accept   string
+		links    []linkReport
+		wantJSON bool
+	}{
+		{
+			name:  "no_accept",
+			links: links,
+		},
+		{
+			name:   "any_accept",
+			accept: "*/*",
+			links:  links,
+		},
+		{
+			name:     "json_accept",
+			accept:   "application/json",
+			links:    links,
+			wantJSON: true,
+		},
+		{
+			name:   "json_accept_without_details",
+			accept: "application/json",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPost, "/api/parse", nil)
+			if len(tt.accept) != 0 {
+				c.Request.Header.Set("Accept", tt.accept)
+			}
+
+			p := newTestProcessor()
+			p.links = tt.links
+			p.respond(c, domain.StatusSuccessfulMatch)
+
+			assert.Equal(t, domain.StatusSuccessfulMatch.Code(), w.Code)
+
+			if !tt.wantJSON {
+				assert.Contains(t, w.Header().Get("Content-Type"), gin.MIMEPlain)
+				assert.Equal(t, domain.StatusSuccessfulMatch.String(), w.Body.String())
+				return
+			}
+
+			assert.Contains(t, w.Header().Get("Content-Type"), gin.MIMEJSON)
+
+			var body struct {
+				Message string       `json:"message"`
+				Links   []linkReport `json:"links"`
+			}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+			assert.Equal(t, domain.StatusSuccessfulMatch.String(), body.Message)
+			assert.Equal(t, tt.links, body.Links)
+		})
+	}
+}
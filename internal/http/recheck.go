@@ -0,0 +1,235 @@
+// Copyright (c) 2023 - 2024, nuxen and the seasonpackarr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package http
+
+import (
+	"context"
+	"time"
+
+	"github.com/nuxencs/seasonpackarr/internal/config"
+	"github.com/nuxencs/seasonpackarr/internal/domain"
+	"github.com/nuxencs/seasonpackarr/internal/logger"
+	"github.com/nuxencs/seasonpackarr/pkg/errors"
+
+	"github.com/autobrr/go-qbittorrent"
+	"github.com/puzpuzpuz/xsync/v3"
+	"github.com/rs/zerolog"
+)
+
+// preparedPollInterval is how often the clients are asked whether the recheck of a prepared season pack finished.
+const preparedPollInterval = 5 * time.Second
+
+// preparedKey identifies a prepared season pack, the same pack might be prepared for more than one client.
+type preparedKey struct {
+	clientName string
+	hash       string
+}
+
+// preparedPack is a season pack whose episodes were linked by the parse endpoint and that is waiting to be added
+// and rechecked by the client. Injected packs aren't tracked here, their recheck is watched by watchRecheck.
+type preparedPack struct {
+	clientName  string
+	hash        string
+	releaseName string
+	preparedAt  time.Time
+	// seenAt is when the pack was first found in the client, zero until then
+	seenAt time.Time
+	// seenChecking is set once the client was seen checking the pack
+	seenChecking bool
+}
+
+var preparedPacks = xsync.NewMapOf[preparedKey, *preparedPack]()
+
+// trackPreparedPack adds the season pack to the packs whose recheck is watched.
+func trackPreparedPack(clientName, hash, releaseName string) {
+	preparedPacks.Store(preparedKey{clientName: clientName, hash: hash}, &preparedPack{
+		clientName:  clientName,
+		hash:        hash,
+		releaseName: releaseName,
+		preparedAt:  time.Now(),
+	})
+}
+
+// recheckWatcher polls the clients for the prepared season packs and reports the result of their recheck. Packs
+// that verified completely and packs with missing pieces are tagged, the latter are paused or resumed depending on
+// the incomplete action.
+type recheckWatcher struct {
+	log  zerolog.Logger
+	cfg  *config.AppConfig
+	noti domain.Sender
+}
+
+func newRecheckWatcher(log logger.Logger, cfg *config.AppConfig, notification domain.Sender) *recheckWatcher {
+	return &recheckWatcher{
+		log:  log.With().Str("module", "recheck").Logger(),
+		cfg:  cfg,
+		noti: notification,
+	}
+}
+
+// Start polls the clients until the context is canceled.
+func (w *recheckWatcher) Start(ctx context.Context) {
+	ticker := time.NewTicker(preparedPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		w.checkAll(ctx)
+	}
+}
+
+// checkAll checks the prepared season packs of every client once.
+func (w *recheckWatcher) checkAll(ctx context.Context) {
+	packsByClient := make(map[string][]*preparedPack)
+	preparedPacks.Range(func(_ preparedKey, pack *preparedPack) bool {
+		packsByClient[pack.clientName] = append(packsByClient[pack.clientName], pack)
+		return true
+	})
+
+	for clientName, packs := range packsByClient {
+		clientCfg, ok := w.cfg.Config.Clients[clientName]
+		if !ok {
+			for _, pack := range packs {
+				preparedPacks.Delete(preparedKey{clientName: pack.clientName, hash: pack.hash})
+			}
+			continue
+		}
+
+		client, err := getClient(clientCfg, clientName)
+		if err != nil {
+			w.log.Error().Err(err).Msgf("error getting client: %s", clientName)
+			continue
+		}
+
+		if err = w.check(ctx, client, packs); err != nil {
+			w.log.Error().Err(err).Msgf("error checking prepared season packs of client: %s", clientName)
+		}
+	}
+}
+
+// check looks up the prepared season packs in the client and handles every pack whose recheck finished. Packs
+// that weren't added or rechecked within the timeout are no longer watched.
+func (w *recheckWatcher) check(ctx context.Context, client *qbittorrent.Client, packs []*preparedPack) error {
+	hashes := make([]string, 0, len(packs))
+	for _, pack := range packs {
+		hashes = append(hashes, pack.hash)
+	}
+
+	found, err := client.GetTorrentsCtx(ctx, qbittorrent.TorrentFilterOptions{Hashes: hashes})
+	if err != nil {
+		return err
+	}
+
+	torrentsByHash := make(map[string]qbittorrent.Torrent, len(found))
+	for _, t := range found {
+		torrentsByHash[t.Hash] = t
+	}
+
+	timeout := time.Duration(max(w.cfg.Config.Recheck.Timeout, 1)) * time.Minute
+	now := time.Now()
+
+	for _, pack := range packs {
+		expired := now.Sub(pack.preparedAt) > timeout
+
+		t, ok := torrentsByHash[pack.hash]
+		if !ok {
+			if expired {
+				w.expire(pack, "season pack wasn't added to the client")
+			}
+			continue
+		}
+
+		if pack.seenAt.IsZero() {
+			pack.seenAt = now
+		}
+
+		checking := isChecking(t.State)
+		pack.seenChecking = pack.seenChecking || checking
+
+		if checking || !pack.seenChecking && now.Sub(pack.seenAt) < recheckStartTimeout {
+			if expired {
+				w.expire(pack, "recheck didn't finish in time")
+			}
+			continue
+		}
+
+		preparedPacks.Delete(preparedKey{clientName: pack.clientName, hash: pack.hash})
+		w.handleResult(ctx, client, pack, t)
+	}
+
+	return nil
+}
+
+// expire stops watching the season pack. Expired packs aren't reported, they might just not have been grabbed.
+func (w *recheckWatcher) expire(pack *preparedPack, reason string) {
+	preparedPacks.Delete(preparedKey{clientName: pack.clientName, hash: pack.hash})
+	w.log.Debug().Msgf("stopped watching season pack: %s: %s", pack.hash, reason)
+}
+
+// handleResult handles the finished recheck of the season pack and reports its result.
+func (w *recheckWatcher) handleResult(ctx context.Context, client *qbittorrent.Client, pack *preparedPack,
+	t qbittorrent.Torrent,
+) {
+	statusCode, err := handleRecheckResult(ctx, w.log, client, w.cfg.Config.Recheck, t)
+	w.notify(statusCode, pack, err)
+}
+
+// handleRecheckResult tags the season pack depending on the result of its recheck and applies the incomplete action
+// to packs with missing pieces. It returns the status to report along with the first error that occurred.
+func handleRecheckResult(ctx context.Context, log zerolog.Logger, client *qbittorrent.Client, recheck domain.Recheck,
+	t qbittorrent.Torrent,
+) (domain.StatusCode, error) {
+	var err error
+	statusCode := domain.StatusRecheckVerified
+	tag := recheck.VerifiedTag
+
+	if t.Progress < 1 {
+		statusCode = domain.StatusRecheckIncomplete
+		tag = recheck.IncompleteTag
+		log.Info().Msgf("season pack is %.2f%% complete after recheck: %s", t.Progress*100, t.Hash)
+
+		switch recheck.IncompleteAction {
+		case domain.IncompleteActionPause:
+			if err = client.PauseCtx(ctx, []string{t.Hash}); err != nil {
+				err = errors.Wrap(err, "could not pause torrent")
+			}
+		case domain.IncompleteActionResume:
+			if err = client.ResumeCtx(ctx, []string{t.Hash}); err != nil {
+				err = errors.Wrap(err, "could not resume torrent")
+			}
+		}
+		if err != nil {
+			log.Error().Err(err).Msgf("error applying incomplete action %q: %s", recheck.IncompleteAction, t.Hash)
+		}
+	} else {
+		log.Info().Msgf("season pack verified after recheck: %s", t.Hash)
+	}
+
+	if len(tag) != 0 {
+		if tagErr := client.AddTagsCtx(ctx, []string{t.Hash}, tag); tagErr != nil {
+			log.Error().Err(tagErr).Msgf("error adding tag %q: %s", tag, t.Hash)
+			if err == nil {
+				err = errors.Wrap(tagErr, "could not add tag")
+			}
+		}
+	}
+
+	return statusCode, err
+}
+
+func (w *recheckWatcher) notify(statusCode domain.StatusCode, pack *preparedPack, err error) {
+	if sendErr := w.noti.Send(statusCode, domain.NotificationPayload{
+		ReleaseName: pack.releaseName,
+		Client:      pack.clientName,
+		Action:      "Recheck",
+		Error:       err,
+	}); sendErr != nil {
+		w.log.Error().Err(sendErr).Msgf("error sending %s notification for %d", w.noti.Name(), statusCode)
+	}
+}
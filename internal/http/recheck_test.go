@@ -0,0 +1,186 @@
+// Copyright (c) 2023 - 2024, nuxen and the seasonpackarr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package http
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nuxencs/seasonpackarr/internal/config"
+	"github.com/nuxencs/seasonpackarr/internal/domain"
+
+	"github.com/autobrr/go-qbittorrent"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClient is a fake qBittorrent that answers every torrent list request with the next list of torrents,
+// repeating the last one, and records the other requests it received.
+type fakeClient struct {
+	mu       sync.Mutex
+	torrents [][]qbittorrent.Torrent
+	calls    int
+	requests []string
+	// files is the JSON file list returned for every torrent
+	files string
+	// webAPIVersion is the WebAPI version of the client, 2.11.2 if empty
+	webAPIVersion string
+}
+
+func newFakeClient(t *testing.T, torrents ...[]qbittorrent.Torrent) (*fakeClient, *qbittorrent.Client) {
+	fc := &fakeClient{torrents: torrents}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fc.mu.Lock()
+		defer fc.mu.Unlock()
+
+		switch r.URL.Path {
+		case "/api/v2/torrents/info":
+			i := min(fc.calls, len(fc.torrents)-1)
+			fc.calls++
+			_ = json.NewEncoder(w).Encode(filterTorrents(fc.torrents[i], r.URL.Query()))
+		case "/api/v2/torrents/files":
+			_, _ = w.Write([]byte(fc.files))
+		case "/api/v2/app/webapiVersion":
+			_, _ = w.Write([]byte(cmp.Or(fc.webAPIVersion, "2.11.2")))
+		default:
+			// parses url encoded forms as well
+			_ = r.ParseMultipartForm(1 << 20)
+			fc.requests = append(fc.requests, r.URL.Path+"?"+r.PostForm.Encode())
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	return fc, qbittorrent.NewClient(qbittorrent.Config{Host: srv.URL})
+}
+
+// filterTorrents applies the hashes, category, tag and filter parameters of a torrent list request like qBittorrent.
+func filterTorrents(ts []qbittorrent.Torrent, query url.Values) []qbittorrent.Torrent {
+	found := make([]qbittorrent.Torrent, 0, len(ts))
+
+	for _, t := range ts {
+		if query.Has("hashes") && !slices.Contains(strings.Split(query.Get("hashes"), "|"), t.Hash) ||
+			query.Has("category") && t.Category != query.Get("category") ||
+			query.Has("tag") && !slices.Contains(strings.Split(t.Tags, ", "), query.Get("tag")) ||
+			query.Get("filter") == string(qbittorrent.TorrentFilterCompleted) && t.Progress < 1 {
+			continue
+		}
+		found = append(found, t)
+	}
+
+	return found
+}
+
+type fakeSender struct {
+	statusCodes []domain.StatusCode
+}
+
+func (s *fakeSender) Name() string { return "fake" }
+
+func (s *fakeSender) Send(statusCode domain.StatusCode, _ domain.NotificationPayload) error {
+	s.statusCodes = append(s.statusCodes, statusCode)
+	return nil
+}
+
+func Test_recheckWatcher_check(t *testing.T) {
+	const hash = "0123456789abcdef0123456789abcdef01234567"
+
+	recheck := domain.Recheck{
+		Enabled:          true,
+		VerifiedTag:      "verified",
+		IncompleteTag:    "incomplete",
+		IncompleteAction: domain.IncompleteActionPause,
+		Timeout:          30,
+	}
+
+	tests := []struct {
+		name         string
+		torrents     [][]qbittorrent.Torrent
+		preparedAt   time.Time
+		wantStatus   []domain.StatusCode
+		wantRequests []string
+		wantTracked  bool
+	}{
+		{
+			name: "verified",
+			torrents: [][]qbittorrent.Torrent{
+				{{Hash: hash, State: qbittorrent.TorrentStateCheckingUp, Progress: 0.2}},
+				{{Hash: hash, State: qbittorrent.TorrentStateStalledUp, Progress: 1}},
+			},
+			preparedAt:   time.Now(),
+			wantStatus:   []domain.StatusCode{domain.StatusRecheckVerified},
+			wantRequests: []string{"/api/v2/torrents/addTags?hashes=" + hash + "&tags=verified"},
+		},
+		{
+			name: "incomplete",
+			torrents: [][]qbittorrent.Torrent{
+				{{Hash: hash, State: qbittorrent.TorrentStateCheckingResumeData}},
+				{{Hash: hash, State: qbittorrent.TorrentStateStalledDl, Progress: 0.9}},
+			},
+			preparedAt: time.Now(),
+			wantStatus: []domain.StatusCode{domain.StatusRecheckIncomplete},
+			wantRequests: []string{
+				"/api/v2/torrents/stop?hashes=" + hash,
+				"/api/v2/torrents/addTags?hashes=" + hash + "&tags=incomplete",
+			},
+		},
+		{
+			name: "still_checking",
+			torrents: [][]qbittorrent.Torrent{
+				{{Hash: hash, State: qbittorrent.TorrentStateCheckingUp, Progress: 0.2}},
+			},
+			preparedAt:  time.Now(),
+			wantTracked: true,
+		},
+		{
+			name:        "not_added_yet",
+			torrents:    [][]qbittorrent.Torrent{{}},
+			preparedAt:  time.Now(),
+			wantTracked: true,
+		},
+		{
+			name:       "not_added_expired",
+			torrents:   [][]qbittorrent.Torrent{{}},
+			preparedAt: time.Now().Add(-time.Hour),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fc, client := newFakeClient(t, tt.torrents...)
+			noti := &fakeSender{}
+
+			w := &recheckWatcher{
+				log:  zerolog.Nop(),
+				cfg:  &config.AppConfig{Config: &domain.Config{Recheck: recheck}},
+				noti: noti,
+			}
+
+			key := preparedKey{clientName: tt.name, hash: hash}
+			trackPreparedPack(tt.name, hash, "Series.Title.S01.1080p.WEB-DL.DDP5.1.H.264-RlsGrp")
+			t.Cleanup(func() { preparedPacks.Delete(key) })
+
+			pack, _ := preparedPacks.Load(key)
+			pack.preparedAt = tt.preparedAt
+
+			for range len(tt.torrents) {
+				require.NoError(t, w.check(context.Background(), client, []*preparedPack{pack}))
+			}
+
+			_, tracked := preparedPacks.Load(key)
+			assert.Equal(t, tt.wantTracked, tracked)
+			assert.Equal(t, tt.wantStatus, noti.statusCodes)
+			assert.Equal(t, tt.wantRequests, fc.requests)
+		})
+	}
+}
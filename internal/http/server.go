@@ -13,6 +13,7 @@ import (
 
 	"github.com/nuxencs/seasonpackarr/internal/config"
 	"github.com/nuxencs/seasonpackarr/internal/domain"
+	"github.com/nuxencs/seasonpackarr/internal/janitor"
 	"github.com/nuxencs/seasonpackarr/internal/logger"
 	"github.com/nuxencs/seasonpackarr/pkg/errors"
 
@@ -23,22 +24,27 @@ import (
 var ErrServerClosed = http.ErrServerClosed
 
 type Server struct {
-	log  logger.Logger
-	cfg  *config.AppConfig
-	noti domain.Sender
+	log     logger.Logger
+	cfg     *config.AppConfig
+	noti    domain.Sender
+	folders *janitor.Store
 
 	httpServer http.Server
 }
 
-func NewServer(log logger.Logger, config *config.AppConfig, notification domain.Sender) *Server {
+func NewServer(log logger.Logger, config *config.AppConfig, notification domain.Sender, folders *janitor.Store) *Server {
 	return &Server{
-		log:  log,
-		cfg:  config,
-		noti: notification,
+		log:     log,
+		cfg:     config,
+		noti:    notification,
+		folders: folders,
 	}
 }
 
 func (s *Server) Open() error {
+	go newRecheckWatcher(s.log, s.cfg, s.noti).Start(context.Background())
+	go newTorrentSync(s.log, s.cfg).Start(context.Background())
+
 	var err error
 	addr := fmt.Sprintf("%s:%d", s.cfg.Config.Host, s.cfg.Config.Port)
 
@@ -94,11 +100,12 @@ func (s *Server) Handler() http.Handler {
 
 	api := g.Group("/api")
 	{
-		newHealthHandler().Routes(api.Group("/healthz"))
+		newHealthHandler(s.log, s.cfg).Routes(api.Group("/healthz"))
 
 		api.Use(s.AuthMiddleware())
 		{
-			newWebhookHandler(s.log, s.cfg, s.noti).Routes(api.Group("/"))
+			newWebhookHandler(s.log, s.cfg, s.noti, s.folders).Routes(api.Group("/"))
+			newStatsHandler().Routes(api.Group("/stats"))
 		}
 	}
 
@@ -0,0 +1,33 @@
+// Copyright (c) 2023 - 2024, nuxen and the seasonpackarr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package http
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type statsHandler struct{}
+
+func newStatsHandler() *statsHandler {
+	return &statsHandler{}
+}
+
+func (h *statsHandler) Routes(r *gin.RouterGroup) {
+	r.GET("", h.handleStats)
+}
+
+// handleStats reports the hits and misses of the file list cache of every client.
+func (h *statsHandler) handleStats(c *gin.Context) {
+	stats := make(map[string]fileCacheStats)
+	fileCaches.Range(func(clientName string, cache *fileCache) bool {
+		stats[clientName] = cache.stats()
+		return true
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"fileCache": stats,
+	})
+}
@@ -0,0 +1,210 @@
+// Copyright (c) 2023 - 2024, nuxen and the seasonpackarr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package http
+
+import (
+	"cmp"
+	"context"
+	"time"
+
+	"github.com/nuxencs/seasonpackarr/internal/config"
+	"github.com/nuxencs/seasonpackarr/internal/domain"
+	"github.com/nuxencs/seasonpackarr/internal/logger"
+
+	"github.com/autobrr/go-qbittorrent"
+	"github.com/moistari/rls"
+	"github.com/rs/zerolog"
+)
+
+// syncStaleIntervals is the number of sync intervals the synced torrent list stays valid without a successful sync.
+// Once it is stale, requests fetch the full torrent list again.
+const syncStaleIntervals = 3
+
+// syncFullUpdateIntervals is the number of sync intervals after which the full torrent list is requested again.
+// Partial updates can't tell a cleared field apart from an unchanged one, the full update picks those up.
+const syncFullUpdateIntervals = 60
+
+// clientSync is the torrent list of a single client as known from the responses of the sync API.
+type clientSync struct {
+	rid         int64
+	updates     int
+	torrents    map[string]qbittorrent.Torrent
+	rlsMap      map[string]rls.Release
+	entriesMap  map[string][]entry
+	excludedMap map[string][]entry
+}
+
+// torrentSync keeps the torrent lists of the clients up to date in the background. It uses the sync API of
+// qBittorrent, which only returns what changed since the response with the given response id, so pack requests
+// never have to wait for the full torrent list of large clients.
+type torrentSync struct {
+	log     zerolog.Logger
+	cfg     *config.AppConfig
+	clients map[string]*clientSync
+}
+
+func newTorrentSync(log logger.Logger, cfg *config.AppConfig) *torrentSync {
+	return &torrentSync{
+		log:     log.With().Str("module", "sync").Logger(),
+		cfg:     cfg,
+		clients: make(map[string]*clientSync),
+	}
+}
+
+// Start syncs the torrent lists in the configured interval until the context is canceled. The sync options are read
+// before every sync, so changes to them are picked up without a restart. The clients are only read on startup.
+func (s *torrentSync) Start(ctx context.Context) {
+	for {
+		interval := time.Duration(max(s.cfg.Config.Sync.Interval, 1)) * time.Second
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+
+		if !s.cfg.Config.Sync.Enabled {
+			clear(s.clients)
+			continue
+		}
+
+		s.syncAll(ctx, interval)
+	}
+}
+
+// syncAll syncs every client of the config once and forgets the clients that were removed from it.
+func (s *torrentSync) syncAll(ctx context.Context, interval time.Duration) {
+	for clientName := range s.clients {
+		if _, ok := s.cfg.Config.Clients[clientName]; !ok {
+			delete(s.clients, clientName)
+		}
+	}
+
+	for clientName, clientCfg := range s.cfg.Config.Clients {
+		client, err := getClient(clientCfg, clientName)
+		if err != nil {
+			s.log.Error().Err(err).Msgf("error getting client: %s", clientName)
+			continue
+		}
+
+		if err = s.sync(ctx, client, clientName, clientCfg.Filters, interval); err != nil {
+			s.log.Error().Err(err).Msgf("error syncing torrents of client: %s", clientName)
+
+			// start over with a full update
+			delete(s.clients, clientName)
+		}
+	}
+}
+
+// sync requests the changes since the last response and stores the updated torrent list, which stays valid for a
+// few intervals.
+func (s *torrentSync) sync(ctx context.Context, client *qbittorrent.Client, clientName string,
+	filters domain.TorrentFilters, interval time.Duration,
+) error {
+	cs, ok := s.clients[clientName]
+	if !ok {
+		cs = &clientSync{rlsMap: make(map[string]rls.Release)}
+		s.clients[clientName] = cs
+	}
+
+	// request a full update every few intervals to pick up cleared fields
+	if cs.updates >= syncFullUpdateIntervals {
+		cs.rid = 0
+	}
+
+	data, err := client.SyncMainDataCtx(ctx, cs.rid)
+	if err != nil {
+		return err
+	}
+
+	if changed := cs.merge(data); changed || cs.entriesMap == nil {
+		cs.rebuild(clientName, filters, s.cfg.Config.SeparateLanguages)
+	}
+
+	// the release cache of the synced list isn't shared, requests that fall back to a full fetch write to it
+	torrentMap.Store(clientName, &torrentRlsEntries{
+		entriesMap:  cs.entriesMap,
+		excludedMap: cs.excludedMap,
+		rlsMap:      make(map[string]rls.Release),
+		lastUpdated: time.Now().Add(syncStaleIntervals * interval),
+	})
+
+	return nil
+}
+
+// merge applies the changes to the known torrents and reports whether any torrent changed in a way that matters
+// for matching, changes of e.g. the speed or the peers are ignored. Partial updates only contain the fields that
+// changed, so only the fields that were sent are merged into the known torrent.
+func (cs *clientSync) merge(data *qbittorrent.MainData) bool {
+	changed := data.FullUpdate || len(data.TorrentsRemoved) != 0
+
+	if data.FullUpdate || cs.torrents == nil {
+		cs.torrents = make(map[string]qbittorrent.Torrent, len(data.Torrents))
+		cs.updates = 0
+
+		for hash, t := range data.Torrents {
+			t.Hash = hash
+			cs.torrents[hash] = t
+		}
+	} else {
+		cs.updates++
+
+		for hash, partial := range data.Torrents {
+			old, ok := cs.torrents[hash]
+			t := mergeSyncedFields(old, partial)
+			t.Hash = hash
+			cs.torrents[hash] = t
+			changed = changed || !ok || syncedFieldsChanged(old, t)
+		}
+	}
+
+	for _, hash := range data.TorrentsRemoved {
+		delete(cs.torrents, hash)
+	}
+
+	cs.rid = int64(data.Rid)
+
+	return changed
+}
+
+// mergeSyncedFields copies the fields that are used to match or link the torrent and were sent in the partial update
+// to the known torrent. Fields with the zero value weren't sent, cleared ones are picked up by the next full update.
+func mergeSyncedFields(t, partial qbittorrent.Torrent) qbittorrent.Torrent {
+	t.Name = cmp.Or(partial.Name, t.Name)
+	t.SavePath = cmp.Or(partial.SavePath, t.SavePath)
+	t.ContentPath = cmp.Or(partial.ContentPath, t.ContentPath)
+	t.Category = cmp.Or(partial.Category, t.Category)
+	t.Tags = cmp.Or(partial.Tags, t.Tags)
+	t.State = cmp.Or(partial.State, t.State)
+	t.Progress = cmp.Or(partial.Progress, t.Progress)
+	t.Size = cmp.Or(partial.Size, t.Size)
+
+	return t
+}
+
+// syncedFieldsChanged reports whether any field that is used to match or link the torrent changed.
+func syncedFieldsChanged(old, t qbittorrent.Torrent) bool {
+	return old.Name != t.Name || old.SavePath != t.SavePath || old.ContentPath != t.ContentPath ||
+		old.Category != t.Category || old.Tags != t.Tags || old.State != t.State ||
+		old.Progress != t.Progress || old.Size != t.Size
+}
+
+// rebuild groups the known torrents by their formatted title. Torrents that don't pass the filters of the client are
+// kept apart, they are never linked but still count as already in the client.
+func (cs *clientSync) rebuild(clientName string, filters domain.TorrentFilters, separateLanguages bool) {
+	ts := make([]qbittorrent.Torrent, 0, len(cs.torrents))
+	var excluded []qbittorrent.Torrent
+
+	for _, t := range cs.torrents {
+		if matchesFilters(t, filters) {
+			ts = append(ts, t)
+		} else {
+			excluded = append(excluded, t)
+		}
+	}
+
+	cs.entriesMap = newTorrentRlsEntries(ts, cs.rlsMap, separateLanguages, time.Time{}).entriesMap
+	cs.excludedMap = newTorrentRlsEntries(excluded, cs.rlsMap, separateLanguages, time.Time{}).entriesMap
+	getFileCache(clientName).retain(ts)
+}
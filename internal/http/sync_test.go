@@ -0,0 +1,201 @@
+// Copyright (c) 2023 - 2024, nuxen and the seasonpackarr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nuxencs/seasonpackarr/internal/config"
+	"github.com/nuxencs/seasonpackarr/internal/domain"
+	"github.com/nuxencs/seasonpackarr/internal/logger"
+
+	"github.com/autobrr/go-qbittorrent"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newFakeSyncClient serves the responses of the sync API in order, the last one is repeated once all were served.
+func newFakeSyncClient(t *testing.T, responses ...string) *qbittorrent.Client {
+	var (
+		mu    sync.Mutex
+		calls int
+	)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if r.URL.Path != "/api/v2/sync/maindata" {
+			http.NotFound(w, r)
+			return
+		}
+
+		_, _ = w.Write([]byte(responses[min(calls, len(responses)-1)]))
+		calls++
+	}))
+	t.Cleanup(srv.Close)
+
+	return qbittorrent.NewClient(qbittorrent.Config{Host: srv.URL})
+}
+
+func Test_torrentSync_sync(t *testing.T) {
+	const clientName = "sync"
+
+	client := newFakeSyncClient(t,
+		`{"rid":1,"full_update":true,"torrents":{
+			"a":{"name":"Series.Title.S01E01.1080p.WEB-DL.DDP5.1.H.264-RlsGrp","category":"tv","progress":1,"state":"stalledUP"},
+			"b":{"name":"Series.Title.S01E02.1080p.WEB-DL.DDP5.1.H.264-RlsGrp","category":"tv","progress":0.5,"state":"downloading"},
+			"c":{"name":"Movie.Title.2024.1080p.WEB-DL.DDP5.1.H.264-RlsGrp","category":"movies","progress":1,"state":"stalledUP"}}}`,
+		`{"rid":2,"torrents":{"b":{"dlspeed":1024}}}`,
+		`{"rid":3,"torrents":{"b":{"progress":1,"state":"stalledUP"},"c":{"category":""},
+			"d":{"name":"Series.Title.S01E03.1080p.WEB-DL.DDP5.1.H.264-RlsGrp","category":"tv","progress":1,"state":"stalledUP"}},
+			"torrents_removed":["a"]}`,
+		`{"rid":1,"full_update":true,"torrents":{
+			"b":{"name":"Series.Title.S01E02.1080p.WEB-DL.DDP5.1.H.264-RlsGrp","category":"tv","progress":1,"state":"stalledUP"},
+			"c":{"name":"Movie.Title.2024.1080p.WEB-DL.DDP5.1.H.264-RlsGrp","category":"","progress":1,"state":"stalledUP"},
+			"d":{"name":"Series.Title.S01E03.1080p.WEB-DL.DDP5.1.H.264-RlsGrp","category":"tv","progress":1,"state":"stalledUP"}}}`,
+	)
+
+	cfg := &config.AppConfig{Config: &domain.Config{Sync: domain.Sync{Enabled: true, Interval: 10}}}
+	filters := domain.TorrentFilters{Categories: []string{"tv"}}
+
+	s := newTorrentSync(logger.New(cfg.Config), cfg)
+	t.Cleanup(func() { torrentMap.Delete(clientName) })
+
+	tests := []struct {
+		name         string
+		wantRid      int64
+		wantHashes   []string
+		wantEntries  []string
+		wantExcluded []string
+		wantProgress float64
+		wantCategory string
+		fullUpdate   bool
+	}{
+		{
+			name:         "full_update",
+			wantRid:      1,
+			wantHashes:   []string{"a", "b", "c"},
+			wantEntries:  []string{"a", "b"},
+			wantExcluded: []string{"c"},
+			wantProgress: 0.5,
+			wantCategory: "movies",
+		},
+		{
+			name:         "irrelevant_change",
+			wantRid:      2,
+			wantHashes:   []string{"a", "b", "c"},
+			wantEntries:  []string{"a", "b"},
+			wantExcluded: []string{"c"},
+			wantProgress: 0.5,
+			wantCategory: "movies",
+		},
+		{
+			name:         "delta",
+			wantRid:      3,
+			wantHashes:   []string{"b", "c", "d"},
+			wantEntries:  []string{"b", "d"},
+			wantExcluded: []string{"c"},
+			wantProgress: 1,
+			wantCategory: "movies",
+		},
+		{
+			name:         "periodic_full_update",
+			wantRid:      1,
+			wantHashes:   []string{"b", "c", "d"},
+			wantEntries:  []string{"b", "d"},
+			wantExcluded: []string{"c"},
+			wantProgress: 1,
+			wantCategory: "",
+			fullUpdate:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.fullUpdate {
+				s.clients[clientName].updates = syncFullUpdateIntervals
+			}
+
+			require.NoError(t, s.sync(context.Background(), client, clientName, filters, 10*time.Second))
+
+			cs := s.clients[clientName]
+			assert.Equal(t, tt.wantRid, cs.rid)
+
+			var hashes []string
+			for hash, torrent := range cs.torrents {
+				assert.Equal(t, hash, torrent.Hash)
+				hashes = append(hashes, hash)
+			}
+			slices.Sort(hashes)
+			assert.Equal(t, tt.wantHashes, hashes)
+
+			tre, ok := torrentMap.Load(clientName)
+			require.True(t, ok)
+			assert.True(t, tre.lastUpdated.After(time.Now()))
+
+			var entries []string
+			for _, clientEntries := range tre.entriesMap {
+				for _, e := range clientEntries {
+					entries = append(entries, e.t.Hash)
+
+					if e.t.Hash == "b" {
+						assert.Equal(t, tt.wantProgress, e.t.Progress)
+					}
+				}
+			}
+			slices.Sort(entries)
+			assert.Equal(t, tt.wantEntries, entries)
+
+			// torrents that don't pass the filters are kept apart for the already in client check
+			var excluded []string
+			for _, clientEntries := range tre.excludedMap {
+				for _, e := range clientEntries {
+					excluded = append(excluded, e.t.Hash)
+					assert.Equal(t, tt.wantCategory, e.t.Category, "cleared fields are synced by full updates")
+				}
+			}
+			assert.Equal(t, tt.wantExcluded, excluded)
+		})
+	}
+}
+
+func Test_torrentSync_syncAll(t *testing.T) {
+	const response = `{"rid":1,"full_update":true,"torrents":{
+		"a":{"name":"Series.Title.S01E01.1080p.WEB-DL.DDP5.1.H.264-RlsGrp","progress":1,"state":"stalledUP"}}}`
+
+	cfg := &config.AppConfig{Config: &domain.Config{
+		Sync:    domain.Sync{Enabled: true, Interval: 10},
+		Clients: map[string]*domain.Client{"syncall_first": {}},
+	}}
+
+	for _, clientName := range []string{"syncall_first", "syncall_reloaded"} {
+		clientMap.Store(clientName, newFakeSyncClient(t, response))
+		t.Cleanup(func() {
+			clientMap.Delete(clientName)
+			torrentMap.Delete(clientName)
+		})
+	}
+
+	s := newTorrentSync(logger.New(cfg.Config), cfg)
+
+	s.syncAll(context.Background(), 10*time.Second)
+	assert.Contains(t, s.clients, "syncall_first")
+	assert.NotContains(t, s.clients, "syncall_reloaded")
+
+	// a config reload replaces the clients
+	cfg.Config.Clients = map[string]*domain.Client{"syncall_reloaded": {}}
+
+	s.syncAll(context.Background(), 10*time.Second)
+	assert.NotContains(t, s.clients, "syncall_first", "removed clients are forgotten")
+	assert.Contains(t, s.clients, "syncall_reloaded", "added clients are synced")
+
+	_, ok := torrentMap.Load("syncall_reloaded")
+	assert.True(t, ok)
+}
@@ -6,22 +6,25 @@ package http
 import (
 	"github.com/nuxencs/seasonpackarr/internal/config"
 	"github.com/nuxencs/seasonpackarr/internal/domain"
+	"github.com/nuxencs/seasonpackarr/internal/janitor"
 	"github.com/nuxencs/seasonpackarr/internal/logger"
 
 	"github.com/gin-gonic/gin"
 )
 
 type webhookHandler struct {
-	log  logger.Logger
-	cfg  *config.AppConfig
-	noti domain.Sender
+	log     logger.Logger
+	cfg     *config.AppConfig
+	noti    domain.Sender
+	folders *janitor.Store
 }
 
-func newWebhookHandler(log logger.Logger, cfg *config.AppConfig, notification domain.Sender) *webhookHandler {
+func newWebhookHandler(log logger.Logger, cfg *config.AppConfig, notification domain.Sender, folders *janitor.Store) *webhookHandler {
 	return &webhookHandler{
-		log:  log,
-		cfg:  cfg,
-		noti: notification,
+		log:     log,
+		cfg:     cfg,
+		noti:    notification,
+		folders: folders,
 	}
 }
 
@@ -31,9 +34,9 @@ func (h *webhookHandler) Routes(r *gin.RouterGroup) {
 }
 
 func (h *webhookHandler) pack(c *gin.Context) {
-	newProcessor(h.log, h.cfg, h.noti).ProcessSeasonPackHandler(c)
+	newProcessor(h.log, h.cfg, h.noti, h.folders).ProcessSeasonPackHandler(c)
 }
 
 func (h *webhookHandler) parse(c *gin.Context) {
-	newProcessor(h.log, h.cfg, h.noti).ParseTorrentHandler(c)
+	newProcessor(h.log, h.cfg, h.noti, h.folders).ParseTorrentHandler(c)
 }
@@ -0,0 +1,62 @@
+// Copyright (c) 2023 - 2024, nuxen and the seasonpackarr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package janitor
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/nuxencs/seasonpackarr/pkg/errors"
+)
+
+// HistoryFile is the name of the file in the data directory the history is stored in.
+const HistoryFile = "history.jsonl"
+
+const (
+	ActionRemoved = "removed"
+	ActionDryRun  = "dry run"
+)
+
+// HistoryEntry describes what happened to a tracked folder.
+type HistoryEntry struct {
+	Time   time.Time `json:"time"`
+	Action string    `json:"action"`
+	Client string    `json:"client"`
+	Path   string    `json:"path"`
+	Reason string    `json:"reason"`
+}
+
+// History appends entries to a file with one JSON object per line.
+type History struct {
+	filePath string
+	m        sync.Mutex
+}
+
+func NewHistory(filePath string) *History {
+	return &History{filePath: filePath}
+}
+
+func (h *History) Add(entry HistoryEntry) error {
+	h.m.Lock()
+	defer h.m.Unlock()
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return errors.Wrap(err, "could not encode history entry")
+	}
+
+	f, err := os.OpenFile(h.filePath, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return errors.Wrap(err, "could not open history file: %s", h.filePath)
+	}
+	defer f.Close()
+
+	if _, err = f.Write(append(data, '\n')); err != nil {
+		return errors.Wrap(err, "could not write history file: %s", h.filePath)
+	}
+
+	return nil
+}
@@ -0,0 +1,194 @@
+// Copyright (c) 2023 - 2024, nuxen and the seasonpackarr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package janitor
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/nuxencs/seasonpackarr/internal/config"
+	"github.com/nuxencs/seasonpackarr/internal/logger"
+	"github.com/nuxencs/seasonpackarr/internal/utils"
+	"github.com/nuxencs/seasonpackarr/pkg/errors"
+
+	"github.com/autobrr/go-qbittorrent"
+	"github.com/rs/zerolog"
+)
+
+// TorrentLister returns all torrents of a client.
+type TorrentLister func(ctx context.Context, clientName string) ([]qbittorrent.Torrent, error)
+
+// Result is a folder that was removed, or would have been removed in a dry run.
+type Result struct {
+	Folder Folder
+	Action string
+	Reason string
+}
+
+// Janitor removes season pack folders that were created by seasonpackarr, but never claimed by a torrent in the
+// client, e.g. because the pack was never grabbed.
+type Janitor struct {
+	log      zerolog.Logger
+	cfg      *config.AppConfig
+	folders  *Store
+	history  *History
+	torrents TorrentLister
+}
+
+func New(log logger.Logger, cfg *config.AppConfig, folders *Store, history *History, torrents TorrentLister) *Janitor {
+	return &Janitor{
+		log:      log.With().Str("module", "janitor").Logger(),
+		cfg:      cfg,
+		folders:  folders,
+		history:  history,
+		torrents: torrents,
+	}
+}
+
+// Start runs the cleanup in the configured interval until the context is canceled. The config is read before
+// every run, so changes to it are picked up without a restart.
+func (j *Janitor) Start(ctx context.Context) {
+	for {
+		interval := time.Duration(max(j.cfg.Config.Cleanup.Interval, 1)) * time.Minute
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+
+		if !j.cfg.Config.Cleanup.Enabled {
+			continue
+		}
+
+		if _, err := j.Run(ctx, j.cfg.Config.Cleanup.DryRun); err != nil {
+			j.log.Error().Err(err).Msg("error cleaning up folders")
+		}
+	}
+}
+
+// Run checks every tracked folder once. Folders that are claimed by a torrent in the client or that don't exist
+// anymore are no longer tracked. Unclaimed folders older than the max age are removed, unless dryRun is set, in
+// which case they are only returned and added to the history once per folder.
+func (j *Janitor) Run(ctx context.Context, dryRun bool) ([]Result, error) {
+	maxAge := time.Duration(j.cfg.Config.Cleanup.MaxAge) * time.Hour
+
+	tracked, err := j.folders.Folders()
+	if err != nil {
+		return nil, err
+	}
+
+	byClient := make(map[string][]Folder)
+	for _, folder := range tracked {
+		byClient[folder.Client] = append(byClient[folder.Client], folder)
+	}
+
+	var results []Result
+
+	for clientName, folders := range byClient {
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+
+		clientCfg, ok := j.cfg.Config.Clients[clientName]
+		if !ok {
+			j.log.Warn().Msgf("client %q of %d tracked folders not found in config, skipping", clientName, len(folders))
+			continue
+		}
+
+		torrents, err := j.torrents(ctx, clientName)
+		if err != nil {
+			j.log.Error().Err(err).Msgf("error getting torrents of client %q, skipping", clientName)
+			continue
+		}
+
+		claimed := make(map[string]struct{}, len(torrents)*2)
+		for _, t := range torrents {
+			claimed[filepath.Clean(utils.MapRemotePath(t.ContentPath, clientCfg.PathMappings))] = struct{}{}
+			claimed[filepath.Join(utils.MapRemotePath(t.SavePath, clientCfg.PathMappings), t.Name)] = struct{}{}
+		}
+
+		for _, folder := range folders {
+			if _, err := os.Stat(folder.Path); errors.Is(err, fs.ErrNotExist) {
+				j.log.Debug().Msgf("folder doesn't exist anymore, no longer tracking it: %s", folder.Path)
+				j.untrack(folder)
+				continue
+			}
+
+			if _, ok := claimed[filepath.Clean(folder.Path)]; ok {
+				j.log.Debug().Msgf("folder was claimed by a torrent, no longer tracking it: %s", folder.Path)
+				j.untrack(folder)
+				continue
+			}
+
+			age := time.Since(folder.CreatedAt)
+			if age < maxAge {
+				continue
+			}
+
+			// never remove anything that isn't a pack folder directly inside the pre import path
+			if filepath.Dir(filepath.Clean(folder.Path)) != filepath.Clean(clientCfg.PreImportPath) {
+				j.log.Error().Msgf("folder is not inside the preImportPath of client %q, skipping: %s",
+					clientName, folder.Path)
+				continue
+			}
+
+			result := Result{
+				Folder: folder,
+				Action: ActionDryRun,
+				Reason: fmt.Sprintf("no torrent claimed it within %s", age.Round(time.Minute)),
+			}
+
+			if dryRun {
+				j.log.Info().Msgf("dry run, would remove folder: %s (%s)", folder.Path, result.Reason)
+				results = append(results, result)
+
+				if !folder.DryRunReported {
+					j.addHistory(result)
+
+					if err = j.folders.MarkDryRunReported(folder.Path); err != nil {
+						j.log.Error().Err(err).Msgf("error marking folder as reported: %s", folder.Path)
+					}
+				}
+				continue
+			}
+
+			if err = os.RemoveAll(folder.Path); err != nil {
+				j.log.Error().Err(err).Msgf("error removing folder: %s", folder.Path)
+				continue
+			}
+			j.log.Info().Msgf("removed folder: %s (%s)", folder.Path, result.Reason)
+
+			result.Action = ActionRemoved
+			results = append(results, result)
+
+			j.untrack(folder)
+			j.addHistory(result)
+		}
+	}
+
+	return results, nil
+}
+
+func (j *Janitor) addHistory(result Result) {
+	if err := j.history.Add(HistoryEntry{
+		Time:   time.Now(),
+		Action: result.Action,
+		Client: result.Folder.Client,
+		Path:   result.Folder.Path,
+		Reason: result.Reason,
+	}); err != nil {
+		j.log.Error().Err(err).Msg("error adding history entry")
+	}
+}
+
+func (j *Janitor) untrack(folder Folder) {
+	if err := j.folders.Untrack(folder.Path); err != nil {
+		j.log.Error().Err(err).Msgf("error untracking folder: %s", folder.Path)
+	}
+}
@@ -0,0 +1,231 @@
+// Copyright (c) 2023 - 2024, nuxen and the seasonpackarr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package janitor
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nuxencs/seasonpackarr/internal/config"
+	"github.com/nuxencs/seasonpackarr/internal/domain"
+
+	"github.com/autobrr/go-qbittorrent"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Store(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), FoldersFile)
+
+	store, err := NewStore(filePath)
+	require.NoError(t, err)
+	assert.Empty(t, mustFolders(t, store))
+
+	require.NoError(t, store.Track("default", "/data/pre/Series.S01"))
+	require.NoError(t, store.Track("default", "/data/pre/Series.S02"))
+
+	createdAt := mustFolders(t, store)[0].CreatedAt
+	require.NoError(t, store.Track("default", "/data/pre/Series.S01"))
+	assert.Equal(t, createdAt, mustFolders(t, store)[0].CreatedAt, "tracking again keeps the creation time")
+
+	require.NoError(t, store.Untrack("/data/pre/Series.S02"))
+
+	reloaded, err := NewStore(filePath)
+	require.NoError(t, err)
+
+	folders := mustFolders(t, reloaded)
+	require.Len(t, folders, 1)
+	assert.Equal(t, "default", folders[0].Client)
+	assert.Equal(t, "/data/pre/Series.S01", folders[0].Path)
+}
+
+func Test_Store_SharedFile(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), FoldersFile)
+
+	// the server and the cleanup command each open their own store on the same file
+	server, err := NewStore(filePath)
+	require.NoError(t, err)
+	cli, err := NewStore(filePath)
+	require.NoError(t, err)
+
+	require.NoError(t, server.Track("default", "/data/pre/Series.S01"))
+	require.NoError(t, cli.Track("default", "/data/pre/Series.S02"))
+	require.NoError(t, server.Track("default", "/data/pre/Series.S03"))
+	require.NoError(t, cli.Untrack("/data/pre/Series.S01"))
+
+	for _, store := range []*Store{server, cli} {
+		var paths []string
+		for _, folder := range mustFolders(t, store) {
+			paths = append(paths, folder.Path)
+		}
+		assert.Equal(t, []string{"/data/pre/Series.S02", "/data/pre/Series.S03"}, paths)
+	}
+}
+
+func Test_Store_staleLock(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), FoldersFile)
+	lockPath := filePath + ".lock"
+
+	// a lock left behind by a crashed process
+	require.NoError(t, os.WriteFile(lockPath, nil, 0o644))
+	lockedAt := time.Now().Add(-2 * staleLockAge)
+	require.NoError(t, os.Chtimes(lockPath, lockedAt, lockedAt))
+
+	store, err := NewStore(filePath)
+	require.NoError(t, err)
+
+	require.NoError(t, store.Track("default", "/data/pre/Series.S01"))
+	assert.NoFileExists(t, lockPath)
+	assert.Len(t, mustFolders(t, store), 1)
+}
+
+func Test_Janitor_Run(t *testing.T) {
+	preImportPath := t.TempDir()
+
+	tests := []struct {
+		name        string
+		age         time.Duration
+		claimed     bool
+		dryRun      bool
+		wantResult  string
+		wantExists  bool
+		wantTracked bool
+	}{
+		{
+			name:        "stale",
+			age:         100 * time.Hour,
+			wantResult:  ActionRemoved,
+			wantExists:  false,
+			wantTracked: false,
+		},
+		{
+			name:        "stale_dry_run",
+			age:         100 * time.Hour,
+			dryRun:      true,
+			wantResult:  ActionDryRun,
+			wantExists:  true,
+			wantTracked: true,
+		},
+		{
+			name:        "too_young",
+			age:         time.Hour,
+			wantExists:  true,
+			wantTracked: true,
+		},
+		{
+			name:        "claimed",
+			age:         100 * time.Hour,
+			claimed:     true,
+			wantExists:  true,
+			wantTracked: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dataDir := t.TempDir()
+			folderPath := filepath.Join(preImportPath, "Series.Title.S01."+tt.name)
+
+			require.NoError(t, os.MkdirAll(folderPath, 0o755))
+			require.NoError(t, os.WriteFile(filepath.Join(folderPath, "Series.Title.S01E01.mkv"), nil, 0o644))
+
+			store, err := NewStore(filepath.Join(dataDir, FoldersFile))
+			require.NoError(t, err)
+
+			store.folders[folderPath] = Folder{Client: "default", Path: folderPath, CreatedAt: time.Now().Add(-tt.age)}
+			require.NoError(t, store.save())
+
+			var torrents []qbittorrent.Torrent
+			if tt.claimed {
+				// the client sees the pre import path under a different mount
+				torrents = append(torrents, qbittorrent.Torrent{
+					Name:     filepath.Base(folderPath),
+					SavePath: "/downloads/pre",
+				})
+			}
+
+			j := &Janitor{
+				log: zerolog.Nop(),
+				cfg: &config.AppConfig{Config: &domain.Config{
+					Clients: map[string]*domain.Client{
+						"default": {
+							PreImportPath: preImportPath,
+							PathMappings:  []domain.PathMapping{{Remote: "/downloads/pre", Local: preImportPath}},
+						},
+					},
+					Cleanup: domain.Cleanup{MaxAge: 72},
+				}},
+				folders: store,
+				history: NewHistory(filepath.Join(dataDir, HistoryFile)),
+				torrents: func(ctx context.Context, clientName string) ([]qbittorrent.Torrent, error) {
+					return torrents, nil
+				},
+			}
+
+			results, err := j.Run(context.Background(), tt.dryRun)
+			require.NoError(t, err)
+
+			if tt.dryRun {
+				// later dry runs still return the folder, but don't add it to the history again
+				results, err = j.Run(context.Background(), tt.dryRun)
+				require.NoError(t, err)
+			}
+
+			if len(tt.wantResult) == 0 {
+				assert.Empty(t, results)
+			} else {
+				require.Len(t, results, 1)
+				assert.Equal(t, tt.wantResult, results[0].Action)
+			}
+
+			_, err = os.Stat(folderPath)
+			assert.Equal(t, tt.wantExists, err == nil)
+
+			_, tracked := store.folders[folderPath]
+			assert.Equal(t, tt.wantTracked, tracked)
+
+			history := readHistory(t, filepath.Join(dataDir, HistoryFile))
+			if len(tt.wantResult) != 0 {
+				require.Len(t, history, 1)
+				assert.Equal(t, tt.wantResult, history[0].Action)
+				assert.Equal(t, folderPath, history[0].Path)
+			} else {
+				assert.Empty(t, history)
+			}
+		})
+	}
+}
+
+func mustFolders(t *testing.T, store *Store) []Folder {
+	folders, err := store.Folders()
+	require.NoError(t, err)
+
+	return folders
+}
+
+func readHistory(t *testing.T, filePath string) []HistoryEntry {
+	f, err := os.Open(filePath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	require.NoError(t, err)
+	defer f.Close()
+
+	var entries []HistoryEntry
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var entry HistoryEntry
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &entry))
+		entries = append(entries, entry)
+	}
+	require.NoError(t, scanner.Err())
+
+	return entries
+}
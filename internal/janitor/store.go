@@ -0,0 +1,238 @@
+// Copyright (c) 2023 - 2024, nuxen and the seasonpackarr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package janitor
+
+import (
+	"encoding/json"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nuxencs/seasonpackarr/pkg/errors"
+)
+
+// FoldersFile is the name of the file in the data directory the tracked folders are stored in.
+const FoldersFile = "folders.json"
+
+// Folder is a season pack folder that was created by seasonpackarr in the pre import path of a client.
+// DryRunReported is set once a dry run recorded the folder in the history.
+type Folder struct {
+	Client         string    `json:"client"`
+	Path           string    `json:"path"`
+	CreatedAt      time.Time `json:"createdAt"`
+	DryRunReported bool      `json:"dryRunReported,omitempty"`
+}
+
+const (
+	// lockRetryInterval is how often a locked folders file is tried again.
+	lockRetryInterval = 50 * time.Millisecond
+	// lockTimeout is how long to wait for the lock held by another process, e.g. the server while the cleanup
+	// command runs.
+	lockTimeout = 10 * time.Second
+	// staleLockAge is the age after which a lock is considered left behind by a crashed process and removed.
+	staleLockAge = time.Minute
+)
+
+// ErrLockTimeout is returned when the folders file stays locked by another process.
+var ErrLockTimeout = errors.New("timed out waiting for folders file lock")
+
+// Store keeps track of the created folders and persists them to a JSON file, so they survive restarts. The server
+// and the cleanup command share the file, so it is reloaded under a lock file before every change.
+type Store struct {
+	filePath string
+	folders  map[string]Folder
+	m        sync.Mutex
+}
+
+func NewStore(filePath string) (*Store, error) {
+	s := &Store{
+		filePath: filePath,
+		folders:  make(map[string]Folder),
+	}
+
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Track starts tracking a folder of a client. Folders that are already tracked keep their creation time.
+func (s *Store) Track(client, folderPath string) error {
+	return s.update(func() bool {
+		if _, ok := s.folders[folderPath]; ok {
+			return false
+		}
+
+		s.folders[folderPath] = Folder{Client: client, Path: folderPath, CreatedAt: time.Now()}
+		return true
+	})
+}
+
+// Untrack stops tracking a folder.
+func (s *Store) Untrack(folderPath string) error {
+	return s.update(func() bool {
+		if _, ok := s.folders[folderPath]; !ok {
+			return false
+		}
+
+		delete(s.folders, folderPath)
+		return true
+	})
+}
+
+// MarkDryRunReported records that a dry run added the folder to the history.
+func (s *Store) MarkDryRunReported(folderPath string) error {
+	return s.update(func() bool {
+		folder, ok := s.folders[folderPath]
+		if !ok || folder.DryRunReported {
+			return false
+		}
+
+		folder.DryRunReported = true
+		s.folders[folderPath] = folder
+		return true
+	})
+}
+
+// Folders returns all tracked folders sorted by their creation time. The file is reloaded first to pick up the
+// changes of other processes.
+func (s *Store) Folders() ([]Folder, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	// the file is replaced atomically, so reading it doesn't need the lock
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+
+	folders := make([]Folder, 0, len(s.folders))
+	for _, folder := range s.folders {
+		folders = append(folders, folder)
+	}
+
+	slices.SortFunc(folders, func(a, b Folder) int {
+		if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Path, b.Path)
+	})
+
+	return folders, nil
+}
+
+// update reloads the folders under the file lock, applies the change and saves the folders if change reports
+// that it modified them.
+func (s *Store) update(change func() bool) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err = s.load(); err != nil {
+		return err
+	}
+
+	if !change() {
+		return nil
+	}
+
+	return s.save()
+}
+
+// lock creates the lock file next to the folders file and returns a function that removes it again. Lock files
+// older than staleLockAge are removed, so a crashed process can't block the store forever.
+func (s *Store) lock() (func(), error) {
+	lockPath := s.filePath + ".lock"
+
+	if err := os.MkdirAll(filepath.Dir(lockPath), 0o755); err != nil {
+		return nil, errors.Wrap(err, "could not create folders file directory")
+	}
+
+	deadline := time.Now().Add(lockTimeout)
+
+	for {
+		f, err := os.OpenFile(lockPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if err == nil {
+			_ = f.Close()
+			return func() { _ = os.Remove(lockPath) }, nil
+		}
+
+		if !errors.Is(err, fs.ErrExist) {
+			return nil, errors.Wrap(err, "could not create folders file lock: %s", lockPath)
+		}
+
+		if info, statErr := os.Stat(lockPath); statErr == nil && time.Since(info.ModTime()) > staleLockAge {
+			_ = os.Remove(lockPath)
+			continue
+		}
+
+		if time.Now().After(deadline) {
+			return nil, errors.Wrap(ErrLockTimeout, "%s", lockPath)
+		}
+
+		time.Sleep(lockRetryInterval)
+	}
+}
+
+// load replaces the tracked folders with the ones in the folders file. A missing file means no folders are
+// tracked. The caller needs to hold s.m.
+func (s *Store) load() error {
+	data, err := os.ReadFile(s.filePath)
+	if errors.Is(err, fs.ErrNotExist) {
+		clear(s.folders)
+		return nil
+	} else if err != nil {
+		return errors.Wrap(err, "could not read folders file: %s", s.filePath)
+	}
+
+	var folders []Folder
+	if err = json.Unmarshal(data, &folders); err != nil {
+		return errors.Wrap(err, "could not decode folders file: %s", s.filePath)
+	}
+
+	clear(s.folders)
+	for _, folder := range folders {
+		s.folders[folder.Path] = folder
+	}
+
+	return nil
+}
+
+// save writes the tracked folders to a temporary file first, so a crash never leaves a truncated file behind.
+// The caller needs to hold s.m and the file lock.
+func (s *Store) save() error {
+	folders := make([]Folder, 0, len(s.folders))
+	for _, folder := range s.folders {
+		folders = append(folders, folder)
+	}
+
+	data, err := json.MarshalIndent(folders, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "could not encode folders")
+	}
+
+	tmpPath := s.filePath + ".tmp"
+	if err = os.MkdirAll(filepath.Dir(s.filePath), 0o755); err != nil {
+		return errors.Wrap(err, "could not create folders file directory")
+	}
+
+	if err = os.WriteFile(tmpPath, data, 0o644); err != nil {
+		return errors.Wrap(err, "could not write folders file: %s", tmpPath)
+	}
+
+	if err = os.Rename(tmpPath, s.filePath); err != nil {
+		return errors.Wrap(err, "could not replace folders file: %s", s.filePath)
+	}
+
+	return nil
+}
@@ -0,0 +1,98 @@
+// Copyright (c) 2023 - 2024, nuxen and the seasonpackarr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package release
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/moistari/rls"
+)
+
+// seasonPackThreshold is the confidence a release needs to exceed to be classified as a season pack.
+const seasonPackThreshold = 0.5
+
+type SeasonPackHints struct {
+	// Category is the tracker category of the release, empty if unknown.
+	Category string
+	// FilePaths are the paths of all files in the torrent, nil if the torrent isn't available.
+	FilePaths []string
+	// EpisodeExtensions are the extensions of files in FilePaths that are counted as episodes.
+	EpisodeExtensions []string
+}
+
+type SeasonPackClassification struct {
+	IsSeasonPack bool
+	Confidence   float32
+	Reasons      []string
+}
+
+func (c *SeasonPackClassification) add(weight float32, reason string, args ...any) {
+	c.Confidence += weight
+	c.Reasons = append(c.Reasons, fmt.Sprintf("%s (%+.2f)", fmt.Sprintf(reason, args...), weight))
+}
+
+func (c *SeasonPackClassification) String() string {
+	return fmt.Sprintf("confidence %.2f: %s", c.Confidence, strings.Join(c.Reasons, ", "))
+}
+
+// ClassifySeasonPack decides whether a release is a season pack. Every signal taken from the parsed release
+// name, the tracker category and the files of the torrent adds to or subtracts from a neutral confidence of
+// 0.5, so a single mis-parsed field doesn't decide on its own.
+func ClassifySeasonPack(requestRls rls.Release, hints SeasonPackHints) SeasonPackClassification {
+	c := SeasonPackClassification{Confidence: seasonPackThreshold}
+
+	switch {
+	case requestRls.Type.Is(rls.Series):
+		c.add(0.25, "parsed as series")
+	case requestRls.Type.Is(rls.Episode):
+		c.add(-0.25, "parsed as episode")
+	default:
+		c.add(-0.1, "parsed as %s", requestRls.Type)
+	}
+
+	switch {
+	case requestRls.Episode != 0:
+		c.add(-0.3, "episode %d in release name", requestRls.Episode)
+	case requestRls.Series != 0:
+		c.add(0.2, "season %d without episode in release name", requestRls.Series)
+	default:
+		c.add(-0.2, "no season in release name")
+	}
+
+	if len(requestRls.Ext) != 0 {
+		c.add(-0.4, "file extension %q in release name", requestRls.Ext)
+	}
+
+	switch category := strings.ToLower(hints.Category); {
+	case len(category) == 0:
+	case strings.Contains(category, "pack") || strings.Contains(category, "season"):
+		c.add(0.2, "category %q", hints.Category)
+	case strings.Contains(category, "episode"):
+		c.add(-0.2, "category %q", hints.Category)
+	}
+
+	if hints.FilePaths != nil {
+		var episodeFiles int
+		for _, filePath := range hints.FilePaths {
+			if IsValidEpisodeFile(filePath, hints.EpisodeExtensions) {
+				episodeFiles++
+			}
+		}
+
+		switch episodeFiles {
+		case 0:
+			c.add(-0.5, "no episode files in torrent")
+		case 1:
+			c.add(-0.5, "single episode file in torrent")
+		default:
+			c.add(0.4, "%d episode files in torrent", episodeFiles)
+		}
+	}
+
+	c.Confidence = min(max(c.Confidence, 0), 1)
+	c.IsSeasonPack = c.Confidence > seasonPackThreshold
+
+	return c
+}
@@ -0,0 +1,106 @@
+package release
+
+import (
+	"testing"
+
+	"github.com/moistari/rls"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ClassifySeasonPack(t *testing.T) {
+	type args struct {
+		requestName string
+		hints       SeasonPackHints
+	}
+
+	type want struct {
+		isSeasonPack bool
+		confidence   float32
+	}
+
+	tests := []struct {
+		name string
+		args args
+		want want
+	}{
+		{
+			name: "season_pack",
+			args: args{
+				requestName: "Series Title S01 1080p AMZN WEB-DL DDP 5.1 H.264-RlsGrp",
+			},
+			want: want{isSeasonPack: true, confidence: 0.95},
+		},
+		{
+			name: "season_pack_numeric_title",
+			args: args{
+				requestName: "1923 S01 1080p AMZN WEB-DL DDP 5.1 H.264-RlsGrp",
+			},
+			want: want{isSeasonPack: true, confidence: 0.95},
+		},
+		{
+			name: "episode",
+			args: args{
+				requestName: "Series Title S01E01 1080p AMZN WEB-DL DDP 5.1 H.264-RlsGrp",
+			},
+			want: want{isSeasonPack: false, confidence: 0},
+		},
+		{
+			name: "episode_with_extension",
+			args: args{
+				requestName: "Series.Title.S01E01.1080p.AMZN.WEB-DL.DDP5.1.H.264-RlsGrp.mkv",
+			},
+			want: want{isSeasonPack: false, confidence: 0},
+		},
+		{
+			name: "misparsed_season_pack_with_category",
+			args: args{
+				requestName: "Series Title Part 1 1080p AMZN WEB-DL DDP 5.1 H.264-RlsGrp",
+				hints: SeasonPackHints{
+					Category: "TV/Packs",
+					FilePaths: []string{
+						"Series Title Part 1/Series.Title.Part.1.E01.1080p.AMZN.WEB-DL.DDP5.1.H.264-RlsGrp.mkv",
+						"Series Title Part 1/Series.Title.Part.1.E02.1080p.AMZN.WEB-DL.DDP5.1.H.264-RlsGrp.mkv",
+						"Series Title Part 1/Series.Title.Part.1.E03.1080p.AMZN.WEB-DL.DDP5.1.H.264-RlsGrp.mkv",
+					},
+					EpisodeExtensions: []string{"mkv"},
+				},
+			},
+			want: want{isSeasonPack: true, confidence: 0.8},
+		},
+		{
+			name: "single_episode_file",
+			args: args{
+				requestName: "Series Title S01 1080p AMZN WEB-DL DDP 5.1 H.264-RlsGrp",
+				hints: SeasonPackHints{
+					FilePaths: []string{
+						"Series Title S01/Series.Title.S01E01.1080p.AMZN.WEB-DL.DDP5.1.H.264-RlsGrp.mkv",
+						"Series Title S01/Series.Title.S01E01.1080p.AMZN.WEB-DL.DDP5.1.H.264-RlsGrp.nfo",
+					},
+					EpisodeExtensions: []string{"mkv"},
+				},
+			},
+			want: want{isSeasonPack: false, confidence: 0.45},
+		},
+		{
+			name: "episode_category",
+			args: args{
+				requestName: "Series Title S01 1080p AMZN WEB-DL DDP 5.1 H.264-RlsGrp",
+				hints: SeasonPackHints{
+					Category: "TV/Episodes",
+				},
+			},
+			want: want{isSeasonPack: true, confidence: 0.75},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ClassifySeasonPack(rls.ParseString(tt.args.requestName), tt.args.hints)
+
+			assert.Equalf(t, tt.want.isSeasonPack, got.IsSeasonPack, "ClassifySeasonPack(%v, %v): %s",
+				tt.args.requestName, tt.args.hints, got.String())
+			assert.InDeltaf(t, tt.want.confidence, got.Confidence, 0.001, "ClassifySeasonPack(%v, %v): %s",
+				tt.args.requestName, tt.args.hints, got.String())
+			assert.NotEmpty(t, got.Reasons)
+		})
+	}
+}
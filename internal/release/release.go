@@ -5,6 +5,7 @@ package release
 
 import (
 	"path/filepath"
+	"strings"
 
 	"github.com/nuxencs/seasonpackarr/internal/domain"
 	"github.com/nuxencs/seasonpackarr/internal/utils"
@@ -12,13 +13,9 @@ import (
 	"github.com/moistari/rls"
 )
 
+// CheckCandidates compares a season pack against a release in the client. The request needs to be
+// classified as a season pack with ClassifySeasonPack beforehand.
 func CheckCandidates(requestRls, clientRls rls.Release, fuzzyMatching domain.FuzzyMatching) domain.CompareInfo {
-	// check if season pack or no extension
-	if !requestRls.Type.Is(rls.Series) || requestRls.Ext != "" {
-		// not a season pack
-		return domain.CompareInfo{StatusCode: domain.StatusNotASeasonPack}
-	}
-
 	return compareReleases(requestRls, clientRls, fuzzyMatching)
 }
 
@@ -39,7 +36,7 @@ func compareReleases(requestRls, clientRls rls.Release, fuzzyMatching domain.Fuz
 		}
 	}
 
-	if rls.MustNormalize(requestRls.Group) != rls.MustNormalize(clientRls.Group) {
+	if !equalAliases(requestRls.Group, clientRls.Group, fuzzyMatching.GroupAliases) {
 		return domain.CompareInfo{
 			StatusCode:   domain.StatusRlsGrpMismatch,
 			RejectValueA: requestRls.Group,
@@ -88,11 +85,65 @@ func compareReleases(requestRls, clientRls rls.Release, fuzzyMatching domain.Fuz
 		}
 	}
 
-	if requestRls.Collection != clientRls.Collection {
+	// skip comparing the streaming service when one of the releases doesn't have one and
+	// ignoreMissingStreamingService is enabled
+	if !fuzzyMatching.IgnoreMissingStreamingService || (len(requestRls.Collection) != 0 && len(clientRls.Collection) != 0) {
+		if !equalAliases(requestRls.Collection, clientRls.Collection, fuzzyMatching.StreamingServiceAliases) {
+			return domain.CompareInfo{
+				StatusCode:   domain.StatusStreamingServiceMismatch,
+				RejectValueA: requestRls.Collection,
+				RejectValueB: clientRls.Collection,
+			}
+		}
+	}
+
+	// normalize audio formats down to their base format when simplifyAudioCompare is enabled, Atmos is always ignored
+	if fuzzyMatching.SimplifyAudioCompare {
+		requestRls.Audio = utils.SimplifyAudioSlice(requestRls.Audio)
+		clientRls.Audio = utils.SimplifyAudioSlice(clientRls.Audio)
+	} else {
+		requestRls.Audio = utils.StripAudioObjects(requestRls.Audio)
+		clientRls.Audio = utils.StripAudioObjects(clientRls.Audio)
+	}
+
+	if !utils.EqualElements(requestRls.Audio, clientRls.Audio) {
+		return domain.CompareInfo{
+			StatusCode:   domain.StatusAudioMismatch,
+			RejectValueA: requestRls.Audio,
+			RejectValueB: clientRls.Audio,
+		}
+	}
+
+	// skip comparing audio channels when skipChannelsCompare is enabled
+	if !fuzzyMatching.SkipChannelsCompare {
+		if requestRls.Channels != clientRls.Channels {
+			return domain.CompareInfo{
+				StatusCode:   domain.StatusChannelsMismatch,
+				RejectValueA: requestRls.Channels,
+				RejectValueB: clientRls.Channels,
+			}
+		}
+	}
+
+	// normalize video codecs down to their format when simplifyCodecCompare is enabled
+	if fuzzyMatching.SimplifyCodecCompare {
+		requestRls.Codec = utils.SimplifyCodecSlice(requestRls.Codec)
+		clientRls.Codec = utils.SimplifyCodecSlice(clientRls.Codec)
+	}
+
+	if !utils.EqualElements(requestRls.Codec, clientRls.Codec) {
+		return domain.CompareInfo{
+			StatusCode:   domain.StatusCodecMismatch,
+			RejectValueA: requestRls.Codec,
+			RejectValueB: clientRls.Codec,
+		}
+	}
+
+	if !compareLanguages(requestRls.Language, clientRls.Language, fuzzyMatching.LanguageCompare) {
 		return domain.CompareInfo{
-			StatusCode:   domain.StatusStreamingServiceMismatch,
-			RejectValueA: requestRls.Collection,
-			RejectValueB: clientRls.Collection,
+			StatusCode:   domain.StatusLanguageMismatch,
+			RejectValueA: requestRls.Language,
+			RejectValueB: clientRls.Language,
 		}
 	}
 
@@ -103,7 +154,9 @@ func compareReleases(requestRls, clientRls rls.Release, fuzzyMatching domain.Fuz
 	return domain.CompareInfo{StatusCode: domain.StatusSuccessfulMatch}
 }
 
-func MatchEpToSeasonPackEp(clientEpPath string, clientEpSize int64, torrentEpPath string, torrentEpSize int64) (string, domain.CompareInfo) {
+func MatchEpToSeasonPackEp(clientEpPath string, clientEpSize int64, torrentEpPath string, torrentEpSize int64,
+	groupAliases [][]string,
+) (string, domain.CompareInfo) {
 	if clientEpSize != torrentEpSize {
 		return "", domain.CompareInfo{
 			StatusCode:   domain.StatusSizeMismatch,
@@ -134,7 +187,7 @@ func MatchEpToSeasonPackEp(clientEpPath string, clientEpSize int64, torrentEpPat
 			RejectValueA: clientEpRls.Resolution,
 			RejectValueB: torrentEpRls.Resolution,
 		}
-	case rls.MustNormalize(clientEpRls.Group) != rls.MustNormalize(torrentEpRls.Group):
+	case !equalAliases(clientEpRls.Group, torrentEpRls.Group, groupAliases):
 		return "", domain.CompareInfo{
 			StatusCode:   domain.StatusRlsGrpMismatch,
 			RejectValueA: clientEpRls.Group,
@@ -145,6 +198,121 @@ func MatchEpToSeasonPackEp(clientEpPath string, clientEpSize int64, torrentEpPat
 	return torrentEpPath, domain.CompareInfo{}
 }
 
+// MatchCompanionToSeasonPackFile matches a companion file of an episode in the client, e.g. a subtitle or NFO,
+// to a file in the season pack. Companion files often don't carry the episode in their own name, so the
+// episode is taken from the closest folder of the torrent file that contains one.
+func MatchCompanionToSeasonPackFile(clientFilePath string, clientFileSize int64, clientEpPath string,
+	torrentFilePath string, torrentFileSize int64,
+) (string, domain.CompareInfo) {
+	if clientFileSize != torrentFileSize {
+		return "", domain.CompareInfo{
+			StatusCode:   domain.StatusSizeMismatch,
+			RejectValueA: clientFileSize,
+			RejectValueB: torrentFileSize,
+		}
+	}
+
+	if !strings.EqualFold(filepath.Ext(clientFilePath), filepath.Ext(torrentFilePath)) {
+		return "", domain.CompareInfo{
+			StatusCode:   domain.StatusCompanionMismatch,
+			RejectValueA: filepath.Ext(clientFilePath),
+			RejectValueB: filepath.Ext(torrentFilePath),
+		}
+	}
+
+	torrentEpRls, ok := episodeFromPath(torrentFilePath)
+	if !ok {
+		// without an episode in the path only identical file names are safe to match
+		if !strings.EqualFold(filepath.Base(clientFilePath), filepath.Base(torrentFilePath)) {
+			return "", domain.CompareInfo{
+				StatusCode:   domain.StatusCompanionMismatch,
+				RejectValueA: filepath.Base(clientFilePath),
+				RejectValueB: filepath.Base(torrentFilePath),
+			}
+		}
+
+		return torrentFilePath, domain.CompareInfo{}
+	}
+
+	clientEpRls := rls.ParseString(filepath.Base(clientEpPath))
+
+	switch {
+	case clientEpRls.Series != torrentEpRls.Series:
+		return "", domain.CompareInfo{
+			StatusCode:   domain.StatusSeasonMismatch,
+			RejectValueA: clientEpRls.Series,
+			RejectValueB: torrentEpRls.Series,
+		}
+	case clientEpRls.Episode != torrentEpRls.Episode:
+		return "", domain.CompareInfo{
+			StatusCode:   domain.StatusEpisodeMismatch,
+			RejectValueA: clientEpRls.Episode,
+			RejectValueB: torrentEpRls.Episode,
+		}
+	}
+
+	return torrentFilePath, domain.CompareInfo{}
+}
+
+// episodeFromPath parses the components of a slash separated path from the deepest up and returns the
+// first one that contains an episode.
+func episodeFromPath(filePath string) (rls.Release, bool) {
+	parts := strings.Split(filepath.ToSlash(filePath), "/")
+
+	for i := len(parts) - 1; i >= 0; i-- {
+		if r := rls.ParseString(parts[i]); r.Episode != 0 {
+			return r, true
+		}
+	}
+
+	return rls.Release{}, false
+}
+
+// compareLanguages compares the languages of two releases according to languageCompare. With the subset
+// policy the languages of one release have to be contained in the other, but a release without any language
+// tags never matches a tagged one, because untagged releases are usually English.
+func compareLanguages(languagesA, languagesB []string, languageCompare string) bool {
+	switch languageCompare {
+	case domain.LanguageCompareIgnore:
+		return true
+	case domain.LanguageCompareSubset:
+		if len(languagesA) == 0 || len(languagesB) == 0 {
+			return len(languagesA) == len(languagesB)
+		}
+
+		return utils.ContainsElements(languagesA, languagesB) || utils.ContainsElements(languagesB, languagesA)
+	default:
+		return utils.EqualElements(languagesA, languagesB)
+	}
+}
+
+// equalAliases reports whether two values, e.g. release groups or streaming services, are the same,
+// either by their normalized names or by being listed in the same set of aliases.
+func equalAliases(valueA, valueB string, aliasSets [][]string) bool {
+	normA, normB := rls.MustNormalize(valueA), rls.MustNormalize(valueB)
+	if normA == normB {
+		return true
+	}
+
+	for _, aliases := range aliasSets {
+		var foundA, foundB bool
+		for _, alias := range aliases {
+			switch rls.MustNormalize(alias) {
+			case normA:
+				foundA = true
+			case normB:
+				foundB = true
+			}
+		}
+
+		if foundA && foundB {
+			return true
+		}
+	}
+
+	return false
+}
+
 func PercentOfTotalEpisodes(totalEps int, foundEps int) float32 {
 	if totalEps == 0 {
 		return 0
@@ -153,16 +321,23 @@ func PercentOfTotalEpisodes(totalEps int, foundEps int) float32 {
 	return float32(foundEps) / float32(totalEps)
 }
 
-func IsValidEpisodeFile(torrentFileName string) bool {
-	torrentFileRls := rls.ParseString(filepath.Base(torrentFileName))
-
+func IsValidEpisodeFile(torrentFileName string, extensions []string) bool {
 	// ignore non video files
-	if torrentFileRls.Ext != "mkv" {
+	if !utils.HasExtension(torrentFileName, extensions) {
 		return false
 	}
 
 	// ignore sample files
-	if rls.MustNormalize(torrentFileRls.Group) == "sample" {
+	return !utils.IsSampleFile(torrentFileName)
+}
+
+func IsCompanionFile(fileName string, companionFiles domain.CompanionFiles, episodeExtensions []string) bool {
+	// ignore video files, they are handled as episodes
+	if utils.HasExtension(fileName, episodeExtensions) {
+		return false
+	}
+
+	if !utils.MatchesGlobs(fileName, companionFiles.Include) || utils.MatchesGlobs(fileName, companionFiles.Exclude) {
 		return false
 	}
 
@@ -5,15 +5,329 @@ import (
 
 	"github.com/nuxencs/seasonpackarr/internal/domain"
 
+	"github.com/moistari/rls"
 	"github.com/stretchr/testify/assert"
 )
 
+func Test_CheckCandidates(t *testing.T) {
+	type args struct {
+		requestName   string
+		clientName    string
+		fuzzyMatching domain.FuzzyMatching
+	}
+
+	tests := []struct {
+		name string
+		args args
+		want domain.CompareInfo
+	}{
+		{
+			name: "found_match",
+			args: args{
+				requestName: "Series Title S01 1080p AMZN WEB-DL DDP 5.1 H.264-RlsGrp",
+				clientName:  "Series Title S01E01 1080p AMZN WEB-DL DDP 5.1 H.264-RlsGrp",
+			},
+			want: domain.CompareInfo{StatusCode: domain.StatusSuccessfulMatch},
+		},
+		{
+			name: "wrong_rlsgrp",
+			args: args{
+				requestName: "Series Title S01 1080p AMZN WEB-DL DDP 5.1 H.264-RlsGrp",
+				clientName:  "Series Title S01E01 1080p AMZN WEB-DL DDP 5.1 H.264-OtherRlsGrp",
+			},
+			want: domain.CompareInfo{
+				StatusCode:   domain.StatusRlsGrpMismatch,
+				RejectValueA: "RlsGrp",
+				RejectValueB: "OtherRlsGrp",
+			},
+		},
+		{
+			name: "aliased_rlsgrp",
+			args: args{
+				requestName: "Series Title S01 1080p AMZN WEB-DL DDP 5.1 H.264-RlsGrp",
+				clientName:  "Series Title S01E01 1080p AMZN WEB-DL DDP 5.1 H.264-OtherRlsGrp",
+				fuzzyMatching: domain.FuzzyMatching{
+					GroupAliases: [][]string{{"rlsgrp", "OTHERRLSGRP"}},
+				},
+			},
+			want: domain.CompareInfo{StatusCode: domain.StatusSuccessfulMatch},
+		},
+		{
+			name: "aliased_rlsgrp_merged_from_file",
+			args: args{
+				requestName: "Series Title S01 1080p AMZN WEB-DL DDP 5.1 H.264-RlsGrp",
+				clientName:  "Series Title S01E01 1080p AMZN WEB-DL DDP 5.1 H.264-OtherRlsGrp",
+				fuzzyMatching: domain.FuzzyMatching{
+					// the first set is defined in the config, the second one was merged from groupAliasesFile
+					GroupAliases: [][]string{{"ThirdRlsGrp", "FourthRlsGrp"}, {"OtherRlsGrp", "RlsGrp"}},
+				},
+			},
+			want: domain.CompareInfo{StatusCode: domain.StatusSuccessfulMatch},
+		},
+		{
+			name: "wrong_rlsgrp_with_unrelated_aliases",
+			args: args{
+				requestName: "Series Title S01 1080p AMZN WEB-DL DDP 5.1 H.264-RlsGrp",
+				clientName:  "Series Title S01E01 1080p AMZN WEB-DL DDP 5.1 H.264-OtherRlsGrp",
+				fuzzyMatching: domain.FuzzyMatching{
+					GroupAliases: [][]string{{"RlsGrp", "ThirdRlsGrp"}, {"OtherRlsGrp", "FourthRlsGrp"}},
+				},
+			},
+			want: domain.CompareInfo{
+				StatusCode:   domain.StatusRlsGrpMismatch,
+				RejectValueA: "RlsGrp",
+				RejectValueB: "OtherRlsGrp",
+			},
+		},
+		{
+			name: "wrong_streaming_service",
+			args: args{
+				requestName: "Series Title S01 1080p AMZN WEB-DL DDP 5.1 H.264-RlsGrp",
+				clientName:  "Series Title S01E01 1080p NF WEB-DL DDP 5.1 H.264-RlsGrp",
+			},
+			want: domain.CompareInfo{
+				StatusCode:   domain.StatusStreamingServiceMismatch,
+				RejectValueA: "AMZN",
+				RejectValueB: "NF",
+			},
+		},
+		{
+			name: "missing_streaming_service",
+			args: args{
+				requestName: "Series Title S01 1080p WEB-DL DDP 5.1 H.264-RlsGrp",
+				clientName:  "Series Title S01E01 1080p AMZN WEB-DL DDP 5.1 H.264-RlsGrp",
+			},
+			want: domain.CompareInfo{
+				StatusCode:   domain.StatusStreamingServiceMismatch,
+				RejectValueA: "",
+				RejectValueB: "AMZN",
+			},
+		},
+		{
+			name: "missing_streaming_service_ignored",
+			args: args{
+				requestName: "Series Title S01 1080p WEB-DL DDP 5.1 H.264-RlsGrp",
+				clientName:  "Series Title S01E01 1080p AMZN WEB-DL DDP 5.1 H.264-RlsGrp",
+				fuzzyMatching: domain.FuzzyMatching{
+					IgnoreMissingStreamingService: true,
+				},
+			},
+			want: domain.CompareInfo{StatusCode: domain.StatusSuccessfulMatch},
+		},
+		{
+			name: "wrong_streaming_service_missing_ignored",
+			args: args{
+				requestName: "Series Title S01 1080p AMZN WEB-DL DDP 5.1 H.264-RlsGrp",
+				clientName:  "Series Title S01E01 1080p NF WEB-DL DDP 5.1 H.264-RlsGrp",
+				fuzzyMatching: domain.FuzzyMatching{
+					IgnoreMissingStreamingService: true,
+				},
+			},
+			want: domain.CompareInfo{
+				StatusCode:   domain.StatusStreamingServiceMismatch,
+				RejectValueA: "AMZN",
+				RejectValueB: "NF",
+			},
+		},
+		{
+			name: "aliased_streaming_service",
+			args: args{
+				requestName: "Series Title S01 1080p AMZN WEB-DL DDP 5.1 H.264-RlsGrp",
+				clientName:  "Series Title S01E01 1080p NF WEB-DL DDP 5.1 H.264-RlsGrp",
+				fuzzyMatching: domain.FuzzyMatching{
+					StreamingServiceAliases: [][]string{{"AMZN", "NF"}},
+				},
+			},
+			want: domain.CompareInfo{StatusCode: domain.StatusSuccessfulMatch},
+		},
+		{
+			name: "wrong_audio",
+			args: args{
+				requestName: "Series Title S01 1080p AMZN WEB-DL DDP 5.1 H.264-RlsGrp",
+				clientName:  "Series Title S01E01 1080p AMZN WEB-DL AAC 5.1 H.264-RlsGrp",
+			},
+			want: domain.CompareInfo{
+				StatusCode:   domain.StatusAudioMismatch,
+				RejectValueA: []string{"DDP"},
+				RejectValueB: []string{"AAC"},
+			},
+		},
+		{
+			name: "atmos_audio",
+			args: args{
+				requestName: "Series Title S01 1080p AMZN WEB-DL DDP 5.1 Atmos H.264-RlsGrp",
+				clientName:  "Series Title S01E01 1080p AMZN WEB-DL DDP 5.1 H.264-RlsGrp",
+			},
+			want: domain.CompareInfo{StatusCode: domain.StatusSuccessfulMatch},
+		},
+		{
+			name: "atmos_audio_ddpa",
+			args: args{
+				requestName: "Series Title S01 1080p AMZN WEB-DL DDP 5.1 Atmos H.264-RlsGrp",
+				clientName:  "Series Title S01E01 1080p AMZN WEB-DL DDPA 5.1 H.264-RlsGrp",
+			},
+			want: domain.CompareInfo{StatusCode: domain.StatusSuccessfulMatch},
+		},
+		{
+			name: "dts_audio",
+			args: args{
+				requestName: "Series Title S01 1080p AMZN WEB-DL DTS-HD MA 5.1 H.264-RlsGrp",
+				clientName:  "Series Title S01E01 1080p AMZN WEB-DL DTS 5.1 H.264-RlsGrp",
+			},
+			want: domain.CompareInfo{
+				StatusCode:   domain.StatusAudioMismatch,
+				RejectValueA: []string{"DTS-HD.MA"},
+				RejectValueB: []string{"DTS"},
+			},
+		},
+		{
+			name: "dts_audio_simplified",
+			args: args{
+				requestName: "Series Title S01 1080p AMZN WEB-DL DTS-HD MA 5.1 H.264-RlsGrp",
+				clientName:  "Series Title S01E01 1080p AMZN WEB-DL DTS 5.1 H.264-RlsGrp",
+				fuzzyMatching: domain.FuzzyMatching{
+					SimplifyAudioCompare: true,
+				},
+			},
+			want: domain.CompareInfo{StatusCode: domain.StatusSuccessfulMatch},
+		},
+		{
+			name: "wrong_channels",
+			args: args{
+				requestName: "Series Title S01 1080p AMZN WEB-DL DDP 5.1 H.264-RlsGrp",
+				clientName:  "Series Title S01E01 1080p AMZN WEB-DL DDP 2.0 H.264-RlsGrp",
+			},
+			want: domain.CompareInfo{
+				StatusCode:   domain.StatusChannelsMismatch,
+				RejectValueA: "5.1",
+				RejectValueB: "2.0",
+			},
+		},
+		{
+			name: "wrong_channels_skipped",
+			args: args{
+				requestName: "Series Title S01 1080p AMZN WEB-DL DDP 5.1 H.264-RlsGrp",
+				clientName:  "Series Title S01E01 1080p AMZN WEB-DL DDP 2.0 H.264-RlsGrp",
+				fuzzyMatching: domain.FuzzyMatching{
+					SkipChannelsCompare: true,
+				},
+			},
+			want: domain.CompareInfo{StatusCode: domain.StatusSuccessfulMatch},
+		},
+		{
+			name: "wrong_codec",
+			args: args{
+				requestName: "Series Title S01 1080p AMZN WEB-DL DDP 5.1 H.264-RlsGrp",
+				clientName:  "Series Title S01E01 1080p AMZN WEB-DL DDP 5.1 x265-RlsGrp",
+			},
+			want: domain.CompareInfo{
+				StatusCode:   domain.StatusCodecMismatch,
+				RejectValueA: []string{"H.264"},
+				RejectValueB: []string{"x265"},
+			},
+		},
+		{
+			name: "codec_simplified",
+			args: args{
+				requestName: "Series Title S01 1080p AMZN WEB-DL DDP 5.1 H.264-RlsGrp",
+				clientName:  "Series Title S01E01 1080p AMZN WEB-DL DDP 5.1 AVC-RlsGrp",
+				fuzzyMatching: domain.FuzzyMatching{
+					SimplifyCodecCompare: true,
+				},
+			},
+			want: domain.CompareInfo{StatusCode: domain.StatusSuccessfulMatch},
+		},
+		{
+			name: "wrong_codec_simplified",
+			args: args{
+				requestName: "Series Title S01 1080p AMZN WEB-DL DDP 5.1 H.264-RlsGrp",
+				clientName:  "Series Title S01E01 1080p AMZN WEB-DL DDP 5.1 x265-RlsGrp",
+				fuzzyMatching: domain.FuzzyMatching{
+					SimplifyCodecCompare: true,
+				},
+			},
+			want: domain.CompareInfo{
+				StatusCode:   domain.StatusCodecMismatch,
+				RejectValueA: []string{"H.264"},
+				RejectValueB: []string{"H.265"},
+			},
+		},
+		{
+			name: "wrong_language",
+			args: args{
+				requestName: "Series Title S01 GERMAN DL 1080p AMZN WEB-DL DDP 5.1 H.264-RlsGrp",
+				clientName:  "Series Title S01E01 1080p AMZN WEB-DL DDP 5.1 H.264-RlsGrp",
+			},
+			want: domain.CompareInfo{
+				StatusCode:   domain.StatusLanguageMismatch,
+				RejectValueA: []string{"GERMAN", "DL"},
+				RejectValueB: []string(nil),
+			},
+		},
+		{
+			name: "wrong_language_ignored",
+			args: args{
+				requestName: "Series Title S01 GERMAN DL 1080p AMZN WEB-DL DDP 5.1 H.264-RlsGrp",
+				clientName:  "Series Title S01E01 1080p AMZN WEB-DL DDP 5.1 H.264-RlsGrp",
+				fuzzyMatching: domain.FuzzyMatching{
+					LanguageCompare: domain.LanguageCompareIgnore,
+				},
+			},
+			want: domain.CompareInfo{StatusCode: domain.StatusSuccessfulMatch},
+		},
+		{
+			name: "language_subset",
+			args: args{
+				requestName: "Series Title S01 GERMAN DL 1080p AMZN WEB-DL DDP 5.1 H.264-RlsGrp",
+				clientName:  "Series Title S01E01 GERMAN 1080p AMZN WEB-DL DDP 5.1 H.264-RlsGrp",
+				fuzzyMatching: domain.FuzzyMatching{
+					LanguageCompare: domain.LanguageCompareSubset,
+				},
+			},
+			want: domain.CompareInfo{StatusCode: domain.StatusSuccessfulMatch},
+		},
+		{
+			name: "language_subset_untagged",
+			args: args{
+				requestName: "Series Title S01 GERMAN DL 1080p AMZN WEB-DL DDP 5.1 H.264-RlsGrp",
+				clientName:  "Series Title S01E01 1080p AMZN WEB-DL DDP 5.1 H.264-RlsGrp",
+				fuzzyMatching: domain.FuzzyMatching{
+					LanguageCompare: domain.LanguageCompareSubset,
+				},
+			},
+			want: domain.CompareInfo{
+				StatusCode:   domain.StatusLanguageMismatch,
+				RejectValueA: []string{"GERMAN", "DL"},
+				RejectValueB: []string(nil),
+			},
+		},
+		{
+			name: "already_in_client",
+			args: args{
+				requestName: "Series Title S01 1080p AMZN WEB-DL DDP 5.1 H.264-RlsGrp",
+				clientName:  "Series Title S01 1080p AMZN WEB-DL DDP 5.1 H.264-RlsGrp",
+			},
+			want: domain.CompareInfo{StatusCode: domain.StatusAlreadyInClient},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requestRls := rls.ParseString(tt.args.requestName)
+			clientRls := rls.ParseString(tt.args.clientName)
+
+			assert.Equalf(t, tt.want, CheckCandidates(requestRls, clientRls, tt.args.fuzzyMatching),
+				"CheckCandidates(%v, %v, %v)", tt.args.requestName, tt.args.clientName, tt.args.fuzzyMatching)
+		})
+	}
+}
+
 func Test_MatchEpToSeasonPackEp(t *testing.T) {
 	type args struct {
 		clientEpPath  string
 		clientEpSize  int64
 		torrentEpPath string
 		torrentEpSize int64
+		groupAliases  [][]string
 	}
 
 	type compare struct {
@@ -107,6 +421,38 @@ func Test_MatchEpToSeasonPackEp(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "aliased_rlsgrp",
+			args: args{
+				clientEpPath:  "Series Title 2022 S02E01 1080p ATVP WEB-DL DDP 5.1 Atmos H.264-RlsGrp.mkv",
+				clientEpSize:  2316560346,
+				torrentEpPath: "Series Title 2022 S02E01 1080p Test ATVP WEB-DL DDP 5.1 Atmos H.264-OtherRlsGrp.mkv",
+				torrentEpSize: 2316560346,
+				groupAliases:  [][]string{{"RlsGrp", "OtherRlsGrp"}},
+			},
+			want: compare{
+				path: "Series Title 2022 S02E01 1080p Test ATVP WEB-DL DDP 5.1 Atmos H.264-OtherRlsGrp.mkv",
+				info: domain.CompareInfo{},
+			},
+		},
+		{
+			name: "wrong_rlsgrp_with_unrelated_aliases",
+			args: args{
+				clientEpPath:  "Series Title 2022 S02E01 1080p ATVP WEB-DL DDP 5.1 Atmos H.264-RlsGrp.mkv",
+				clientEpSize:  2316560346,
+				torrentEpPath: "Series Title 2022 S02E01 1080p Test ATVP WEB-DL DDP 5.1 Atmos H.264-OtherRlsGrp.mkv",
+				torrentEpSize: 2316560346,
+				groupAliases:  [][]string{{"RlsGrp", "ThirdRlsGrp"}, {"OtherRlsGrp", "FourthRlsGrp"}},
+			},
+			want: compare{
+				path: "",
+				info: domain.CompareInfo{
+					StatusCode:   domain.StatusRlsGrpMismatch,
+					RejectValueA: "RlsGrp",
+					RejectValueB: "OtherRlsGrp",
+				},
+			},
+		},
 		{
 			name: "wrong_size",
 			args: args{
@@ -179,7 +525,8 @@ func Test_MatchEpToSeasonPackEp(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			gotPath, gotInfo := MatchEpToSeasonPackEp(tt.args.clientEpPath, tt.args.clientEpSize, tt.args.torrentEpPath, tt.args.torrentEpSize)
+			gotPath, gotInfo := MatchEpToSeasonPackEp(tt.args.clientEpPath, tt.args.clientEpSize, tt.args.torrentEpPath,
+				tt.args.torrentEpSize, tt.args.groupAliases)
 
 			got := compare{
 				path: gotPath,
@@ -195,6 +542,7 @@ func Test_MatchEpToSeasonPackEp(t *testing.T) {
 func Test_IsValidEpisodeFile(t *testing.T) {
 	type args struct {
 		torrentFileName string
+		extensions      []string
 	}
 	tests := []struct {
 		name string
@@ -205,6 +553,7 @@ func Test_IsValidEpisodeFile(t *testing.T) {
 			name: "sample_with_dash",
 			args: args{
 				torrentFileName: "test.release.s06e03.dutch.1080p.web.h264-rlsgrp-sample.mkv",
+				extensions:      []string{"mkv"},
 			},
 			want: false,
 		},
@@ -212,6 +561,7 @@ func Test_IsValidEpisodeFile(t *testing.T) {
 			name: "sample_with_dot",
 			args: args{
 				torrentFileName: "test.release.s06e03.dutch.1080p.web.h264-rlsgrp.sample.mkv",
+				extensions:      []string{"mkv"},
 			},
 			want: false,
 		},
@@ -219,6 +569,7 @@ func Test_IsValidEpisodeFile(t *testing.T) {
 			name: "wrong_ext",
 			args: args{
 				torrentFileName: "test.release.s06e03.dutch.1080p.web.h264-rlsgrp.nfo",
+				extensions:      []string{"mkv"},
 			},
 			want: false,
 		},
@@ -226,6 +577,31 @@ func Test_IsValidEpisodeFile(t *testing.T) {
 			name: "wrong_ext_and_sample",
 			args: args{
 				torrentFileName: "test.release.s06e03.dutch.1080p.web.h264-rlsgrp.sample.nfo",
+				extensions:      []string{"mkv"},
+			},
+			want: false,
+		},
+		{
+			name: "mp4_not_allowed",
+			args: args{
+				torrentFileName: "test.release.s06e03.dutch.1080p.web.h264-rlsgrp.mp4",
+				extensions:      []string{"mkv"},
+			},
+			want: false,
+		},
+		{
+			name: "mp4_allowed",
+			args: args{
+				torrentFileName: "test.release.s06e03.dutch.1080p.web.h264-rlsgrp.mp4",
+				extensions:      []string{"mkv", "mp4"},
+			},
+			want: true,
+		},
+		{
+			name: "avi_sample_rejected",
+			args: args{
+				torrentFileName: "test.release.s06e03.dutch.720p.hdtv.x264-rlsgrp-sample.avi",
+				extensions:      []string{"avi"},
 			},
 			want: false,
 		},
@@ -233,13 +609,231 @@ func Test_IsValidEpisodeFile(t *testing.T) {
 			name: "valid_release",
 			args: args{
 				torrentFileName: "test.release.s06e03.dutch.1080p.web.h264-rlsgrp.mkv",
+				extensions:      []string{"mkv"},
+			},
+			want: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equalf(t, tt.want, IsValidEpisodeFile(tt.args.torrentFileName, tt.args.extensions),
+				"IsValidEpisodeFile(%v, %v)", tt.args.torrentFileName, tt.args.extensions)
+		})
+	}
+}
+
+func Test_MatchCompanionToSeasonPackFile(t *testing.T) {
+	type args struct {
+		clientFilePath  string
+		clientFileSize  int64
+		clientEpPath    string
+		torrentFilePath string
+		torrentFileSize int64
+	}
+
+	type compare struct {
+		path string
+		info domain.CompareInfo
+	}
+
+	tests := []struct {
+		name string
+		args args
+		want compare
+	}{
+		{
+			name: "found_match_subtitle_next_to_episode",
+			args: args{
+				clientFilePath:  "/data/Series.Title.S01E01.1080p.WEB-DL.DDP5.1.H.264-RlsGrp/Series.Title.S01E01.1080p.WEB-DL.DDP5.1.H.264-RlsGrp.en.srt",
+				clientFileSize:  45678,
+				clientEpPath:    "/data/Series.Title.S01E01.1080p.WEB-DL.DDP5.1.H.264-RlsGrp/Series.Title.S01E01.1080p.WEB-DL.DDP5.1.H.264-RlsGrp.mkv",
+				torrentFilePath: "Series.Title.S01E01.1080p.WEB-DL.DDP5.1.H.264-RlsGrp.en.srt",
+				torrentFileSize: 45678,
+			},
+			want: compare{
+				path: "Series.Title.S01E01.1080p.WEB-DL.DDP5.1.H.264-RlsGrp.en.srt",
+				info: domain.CompareInfo{},
+			},
+		},
+		{
+			name: "found_match_subtitle_in_episode_folder",
+			args: args{
+				clientFilePath:  "/data/Series.Title.S01E02.1080p.WEB-DL.DDP5.1.H.264-RlsGrp/Subs/English.srt",
+				clientFileSize:  45678,
+				clientEpPath:    "/data/Series.Title.S01E02.1080p.WEB-DL.DDP5.1.H.264-RlsGrp/Series.Title.S01E02.1080p.WEB-DL.DDP5.1.H.264-RlsGrp.mkv",
+				torrentFilePath: "Subs/Series.Title.S01E02.1080p.WEB-DL.DDP5.1.H.264-RlsGrp/English.srt",
+				torrentFileSize: 45678,
+			},
+			want: compare{
+				path: "Subs/Series.Title.S01E02.1080p.WEB-DL.DDP5.1.H.264-RlsGrp/English.srt",
+				info: domain.CompareInfo{},
+			},
+		},
+		{
+			name: "found_match_without_episode",
+			args: args{
+				clientFilePath:  "/data/Series.Title.S01E01.1080p.WEB-DL.DDP5.1.H.264-RlsGrp/Subs/English.srt",
+				clientFileSize:  45678,
+				clientEpPath:    "/data/Series.Title.S01E01.1080p.WEB-DL.DDP5.1.H.264-RlsGrp/Series.Title.S01E01.1080p.WEB-DL.DDP5.1.H.264-RlsGrp.mkv",
+				torrentFilePath: "Subs/English.srt",
+				torrentFileSize: 45678,
+			},
+			want: compare{
+				path: "Subs/English.srt",
+				info: domain.CompareInfo{},
+			},
+		},
+		{
+			name: "size_not_matching",
+			args: args{
+				clientFilePath:  "/data/Series.Title.S01E01.1080p.WEB-DL.DDP5.1.H.264-RlsGrp/Series.Title.S01E01.1080p.WEB-DL.DDP5.1.H.264-RlsGrp.en.srt",
+				clientFileSize:  45678,
+				clientEpPath:    "/data/Series.Title.S01E01.1080p.WEB-DL.DDP5.1.H.264-RlsGrp/Series.Title.S01E01.1080p.WEB-DL.DDP5.1.H.264-RlsGrp.mkv",
+				torrentFilePath: "Series.Title.S01E01.1080p.WEB-DL.DDP5.1.H.264-RlsGrp.en.srt",
+				torrentFileSize: 45679,
+			},
+			want: compare{
+				path: "",
+				info: domain.CompareInfo{
+					StatusCode:   domain.StatusSizeMismatch,
+					RejectValueA: int64(45678),
+					RejectValueB: int64(45679),
+				},
+			},
+		},
+		{
+			name: "extension_not_matching",
+			args: args{
+				clientFilePath:  "/data/Series.Title.S01E01.1080p.WEB-DL.DDP5.1.H.264-RlsGrp/Series.Title.S01E01.1080p.WEB-DL.DDP5.1.H.264-RlsGrp.nfo",
+				clientFileSize:  45678,
+				clientEpPath:    "/data/Series.Title.S01E01.1080p.WEB-DL.DDP5.1.H.264-RlsGrp/Series.Title.S01E01.1080p.WEB-DL.DDP5.1.H.264-RlsGrp.mkv",
+				torrentFilePath: "Series.Title.S01E01.1080p.WEB-DL.DDP5.1.H.264-RlsGrp.srt",
+				torrentFileSize: 45678,
+			},
+			want: compare{
+				path: "",
+				info: domain.CompareInfo{
+					StatusCode:   domain.StatusCompanionMismatch,
+					RejectValueA: ".nfo",
+					RejectValueB: ".srt",
+				},
+			},
+		},
+		{
+			name: "file_name_not_matching",
+			args: args{
+				clientFilePath:  "/data/Series.Title.S01E01.1080p.WEB-DL.DDP5.1.H.264-RlsGrp/Subs/English.srt",
+				clientFileSize:  45678,
+				clientEpPath:    "/data/Series.Title.S01E01.1080p.WEB-DL.DDP5.1.H.264-RlsGrp/Series.Title.S01E01.1080p.WEB-DL.DDP5.1.H.264-RlsGrp.mkv",
+				torrentFilePath: "Subs/German.srt",
+				torrentFileSize: 45678,
+			},
+			want: compare{
+				path: "",
+				info: domain.CompareInfo{
+					StatusCode:   domain.StatusCompanionMismatch,
+					RejectValueA: "English.srt",
+					RejectValueB: "German.srt",
+				},
+			},
+		},
+		{
+			name: "episode_not_matching",
+			args: args{
+				clientFilePath:  "/data/Series.Title.S01E01.1080p.WEB-DL.DDP5.1.H.264-RlsGrp/Subs/English.srt",
+				clientFileSize:  45678,
+				clientEpPath:    "/data/Series.Title.S01E01.1080p.WEB-DL.DDP5.1.H.264-RlsGrp/Series.Title.S01E01.1080p.WEB-DL.DDP5.1.H.264-RlsGrp.mkv",
+				torrentFilePath: "Subs/Series.Title.S01E02.1080p.WEB-DL.DDP5.1.H.264-RlsGrp/English.srt",
+				torrentFileSize: 45678,
+			},
+			want: compare{
+				path: "",
+				info: domain.CompareInfo{
+					StatusCode:   domain.StatusEpisodeMismatch,
+					RejectValueA: 1,
+					RejectValueB: 2,
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotPath, gotInfo := MatchCompanionToSeasonPackFile(tt.args.clientFilePath, tt.args.clientFileSize,
+				tt.args.clientEpPath, tt.args.torrentFilePath, tt.args.torrentFileSize)
+
+			got := compare{
+				path: gotPath,
+				info: gotInfo,
+			}
+
+			assert.Equalf(t, tt.want, got, "MatchCompanionToSeasonPackFile(%v, %v, %v, %v, %v)",
+				tt.args.clientFilePath, tt.args.clientFileSize, tt.args.clientEpPath, tt.args.torrentFilePath,
+				tt.args.torrentFileSize)
+		})
+	}
+}
+
+func Test_IsCompanionFile(t *testing.T) {
+	companionFiles := domain.CompanionFiles{
+		Enabled: true,
+		Include: domain.DefaultCompanionFileGlobs,
+		Exclude: []string{"*sample*"},
+	}
+
+	type args struct {
+		fileName          string
+		episodeExtensions []string
+	}
+	tests := []struct {
+		name string
+		args args
+		want bool
+	}{
+		{
+			name: "subtitle",
+			args: args{
+				fileName:          "Subs/Series.Title.S01E01.1080p.WEB-DL.DDP5.1.H.264-RlsGrp/English.srt",
+				episodeExtensions: []string{"mkv"},
 			},
 			want: true,
 		},
+		{
+			name: "nfo_uppercase",
+			args: args{
+				fileName:          "Series.Title.S01E01.1080p.WEB-DL.DDP5.1.H.264-RlsGrp.NFO",
+				episodeExtensions: []string{"mkv"},
+			},
+			want: true,
+		},
+		{
+			name: "episode",
+			args: args{
+				fileName:          "Series.Title.S01E01.1080p.WEB-DL.DDP5.1.H.264-RlsGrp.mkv",
+				episodeExtensions: []string{"mkv"},
+			},
+			want: false,
+		},
+		{
+			name: "not_included",
+			args: args{
+				fileName:          "Series.Title.S01E01.1080p.WEB-DL.DDP5.1.H.264-RlsGrp.jpg",
+				episodeExtensions: []string{"mkv"},
+			},
+			want: false,
+		},
+		{
+			name: "excluded",
+			args: args{
+				fileName:          "Series.Title.S01E01.1080p.WEB-DL.DDP5.1.H.264-RlsGrp.sample.srt",
+				episodeExtensions: []string{"mkv"},
+			},
+			want: false,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			assert.Equalf(t, tt.want, IsValidEpisodeFile(tt.args.torrentFileName), "IsValidEpisodeFile(%v)", tt.args.torrentFileName)
+			assert.Equalf(t, tt.want, IsCompanionFile(tt.args.fileName, companionFiles, tt.args.episodeExtensions),
+				"IsCompanionFile(%v, %v)", tt.args.fileName, tt.args.episodeExtensions)
 		})
 	}
 }
@@ -0,0 +1,127 @@
+// Copyright (c) 2023 - 2024, nuxen and the seasonpackarr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package torrents
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/nuxencs/seasonpackarr/pkg/errors"
+)
+
+const (
+	// MaxTorrentSize is the largest torrent file that is accepted, season packs with thousands of files and small
+	// pieces stay well below it.
+	MaxTorrentSize int64 = 10 << 20
+	// fetchTimeout limits how long downloading a torrent file from a URL may take.
+	fetchTimeout = 30 * time.Second
+)
+
+var (
+	ErrTorrentTooLarge = errors.Sentinel("torrent file exceeds %d bytes", MaxTorrentSize)
+	ErrForbiddenHost   = errors.Sentinel("torrent url points to a local or private address")
+)
+
+// ReadTorrent reads a torrent file from r and fails if it is larger than MaxTorrentSize.
+func ReadTorrent(r io.Reader) ([]byte, error) {
+	torrentBytes, err := io.ReadAll(io.LimitReader(r, MaxTorrentSize+1))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read torrent file")
+	}
+
+	if int64(len(torrentBytes)) > MaxTorrentSize {
+		return nil, ErrTorrentTooLarge
+	}
+
+	return torrentBytes, nil
+}
+
+// FetchTorrent downloads a torrent file from an http or https URL and fails if it is larger than MaxTorrentSize.
+// Since the URL comes from the request, it may only point to public addresses, including every redirect, unless
+// its host is one of the allowed hosts.
+func FetchTorrent(ctx context.Context, torrentURL string, allowedHosts []string) ([]byte, error) {
+	u, err := url.Parse(torrentURL)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not parse torrent url")
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, errors.New("unsupported torrent url scheme: %s", u.Scheme)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, fetchTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create torrent request")
+	}
+
+	resp, err := newFetchClient(allowedHosts).Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not fetch torrent")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status fetching torrent: %s", resp.Status)
+	}
+
+	if resp.ContentLength > MaxTorrentSize {
+		return nil, ErrTorrentTooLarge
+	}
+
+	return ReadTorrent(resp.Body)
+}
+
+// newFetchClient returns a client that refuses to connect to local or private addresses, unless the host of the
+// connection is one of the allowed hosts. The addresses are checked after resolving, so neither DNS nor redirects
+// can be used to reach internal services. Proxies aren't used, they would hide the address that is connected to.
+func newFetchClient(allowedHosts []string) *http.Client {
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	publicDialer := &net.Dialer{Timeout: 10 * time.Second, Control: rejectNonPublic}
+
+	return &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+				host, _, err := net.SplitHostPort(addr)
+				if err != nil {
+					return nil, err
+				}
+
+				if slices.ContainsFunc(allowedHosts, func(allowed string) bool { return strings.EqualFold(allowed, host) }) {
+					return dialer.DialContext(ctx, network, addr)
+				}
+
+				return publicDialer.DialContext(ctx, network, addr)
+			},
+			TLSHandshakeTimeout:   10 * time.Second,
+			ResponseHeaderTimeout: fetchTimeout,
+		},
+	}
+}
+
+// rejectNonPublic fails for every resolved address that is loopback, private, link-local, multicast or unspecified.
+func rejectNonPublic(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return errors.Wrap(ErrForbiddenHost, "%s", host)
+	}
+
+	return nil
+}
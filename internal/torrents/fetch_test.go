@@ -0,0 +1,94 @@
+// Copyright (c) 2023 - 2024, nuxen and the seasonpackarr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package torrents
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/nuxencs/seasonpackarr/pkg/errors"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ReadTorrent(t *testing.T) {
+	t.Run("within_limit", func(t *testing.T) {
+		got, err := ReadTorrent(bytes.NewReader([]byte("d4:infod4:name4:testee")))
+		require.NoError(t, err)
+		assert.Equal(t, []byte("d4:infod4:name4:testee"), got)
+	})
+
+	t.Run("too_large", func(t *testing.T) {
+		_, err := ReadTorrent(bytes.NewReader(make([]byte, MaxTorrentSize+1)))
+		assert.True(t, errors.Is(err, ErrTorrentTooLarge))
+	})
+}
+
+func Test_FetchTorrent(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/pack.torrent":
+			_, _ = w.Write([]byte("d4:infod4:name4:testee"))
+		case "/large.torrent":
+			_, _ = w.Write(make([]byte, MaxTorrentSize+1))
+		case "/redirect.torrent":
+			// same server, but reached through a host that isn't allowed
+			http.Redirect(w, r, strings.Replace(server.URL, "127.0.0.1", "localhost", 1)+"/pack.torrent",
+				http.StatusFound)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	allowedHosts := []string{"127.0.0.1"}
+
+	tests := []struct {
+		name          string
+		url           string
+		allowedHosts  []string
+		want          []byte
+		wantErr       bool
+		wantForbidden bool
+	}{
+		{
+			name:         "found",
+			url:          server.URL + "/pack.torrent",
+			allowedHosts: allowedHosts,
+			want:         []byte("d4:infod4:name4:testee"),
+		},
+		{name: "too_large", url: server.URL + "/large.torrent", allowedHosts: allowedHosts, wantErr: true},
+		{name: "not_found", url: server.URL + "/missing.torrent", allowedHosts: allowedHosts, wantErr: true},
+		{name: "unsupported_scheme", url: "file:///etc/passwd", wantErr: true},
+		{name: "loopback", url: server.URL + "/pack.torrent", wantErr: true, wantForbidden: true},
+		{name: "private", url: "http://192.168.1.1/pack.torrent", wantErr: true, wantForbidden: true},
+		{name: "link_local", url: "http://169.254.169.254/latest/meta-data", wantErr: true, wantForbidden: true},
+		{
+			name:          "redirect_to_loopback",
+			url:           server.URL + "/redirect.torrent",
+			allowedHosts:  allowedHosts,
+			wantErr:       true,
+			wantForbidden: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FetchTorrent(context.Background(), tt.url, tt.allowedHosts)
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Equal(t, tt.wantForbidden, errors.Is(err, ErrForbiddenHost))
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
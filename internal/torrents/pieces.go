@@ -0,0 +1,181 @@
+// Copyright (c) 2023 - 2024, nuxen and the seasonpackarr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package torrents
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/nuxencs/seasonpackarr/pkg/errors"
+
+	"github.com/anacrolix/torrent/metainfo"
+)
+
+var (
+	ErrPieceMismatch      = errors.Sentinel("piece hash mismatch")
+	ErrNoVerifiablePieces = errors.Sentinel("no piece lies completely within the file")
+)
+
+// Readiness describes how much of a torrent will be complete after a recheck in the client.
+type Readiness struct {
+	TotalPieces    int     `json:"totalPieces"`
+	CompletePieces int     `json:"completePieces"`
+	TotalSize      int64   `json:"totalSize"`
+	CompleteSize   int64   `json:"completeSize"`
+	Completion     float32 `json:"completion"`
+}
+
+// findFile returns the file of the torrent with the given display path, including its offset in the torrent data.
+func findFile(info *metainfo.Info, torrentFilePath string) (metainfo.FileInfo, bool) {
+	for _, file := range info.UpvertedV1Files() {
+		if file.DisplayPath(info) == torrentFilePath {
+			return file, true
+		}
+	}
+
+	return metainfo.FileInfo{}, false
+}
+
+// filePieceRange returns the range [begin, end) of pieces that lie completely within the given file and can
+// therefore be verified with the data of that file alone.
+func filePieceRange(info *metainfo.Info, file metainfo.FileInfo) (int, int) {
+	fileEnd := file.TorrentOffset + file.Length
+
+	begin := int((file.TorrentOffset + info.PieceLength - 1) / info.PieceLength)
+	end := int(fileEnd / info.PieceLength)
+
+	// the last piece of the torrent is shorter than the others, so it ends with the last file
+	if fileEnd == info.TotalLength() {
+		end = info.NumPieces()
+	}
+
+	return begin, max(begin, end)
+}
+
+// samplePieces spreads the given number of samples evenly over the range [begin, end), always including
+// the first and the last piece. All pieces are returned if samples is zero or not less than the range.
+func samplePieces(begin, end, samples int) []int {
+	count := end - begin
+	if samples <= 0 || samples >= count {
+		samples = count
+	}
+
+	pieces := make([]int, 0, samples)
+
+	switch samples {
+	case 0:
+	case 1:
+		pieces = append(pieces, begin+count/2)
+	default:
+		for i := 0; i < samples; i++ {
+			pieces = append(pieces, begin+i*(count-1)/(samples-1))
+		}
+	}
+
+	return pieces
+}
+
+// VerifyFilePieces hashes the pieces of the file at torrentFilePath that lie completely within it against the data
+// of the local file at filePath. With samples greater than zero only that many pieces, spread evenly over the file,
+// are hashed to limit I/O. It returns the number of verified pieces, files smaller than two pieces might not
+// contain a single piece that can be verified on its own, which fails with ErrNoVerifiablePieces.
+func VerifyFilePieces(info metainfo.Info, torrentFilePath, filePath string, samples int) (int, error) {
+	if !info.HasV1() || info.PieceLength <= 0 {
+		return 0, errors.New("torrent has no v1 pieces")
+	}
+
+	file, ok := findFile(&info, torrentFilePath)
+	if !ok {
+		return 0, errors.New("file not found in torrent: %s", torrentFilePath)
+	}
+
+	begin, end := filePieceRange(&info, file)
+	if begin == end {
+		return 0, errors.Wrap(ErrNoVerifiablePieces, "%s", torrentFilePath)
+	}
+
+	f, err := os.Open(filePath)
+	if err != nil {
+		return 0, errors.Wrap(err, "could not open file: %s", filePath)
+	}
+	defer f.Close()
+	buf := make([]byte, info.PieceLength)
+
+	var verified int
+
+	for _, i := range samplePieces(begin, end, samples) {
+		piece := info.Piece(i)
+		data := buf[:piece.V1Length()]
+
+		if _, err = f.ReadAt(data, piece.Offset()-file.TorrentOffset); err != nil && !errors.Is(err, io.EOF) {
+			return verified, errors.Wrap(err, "could not read piece %d from file: %s", i, filePath)
+		}
+
+		hash := sha1.Sum(data)
+		if !bytes.Equal(hash[:], info.Pieces[i*sha1.Size:(i+1)*sha1.Size]) {
+			return verified, errors.Wrap(ErrPieceMismatch, "piece %d of %s", i, torrentFilePath)
+		}
+
+		verified++
+	}
+
+	return verified, nil
+}
+
+// GetReadiness calculates which pieces of the torrent can be completed with the given linked files. A piece is
+// only complete if every file it overlaps is linked, so pieces at the boundary of a missing file are lost even
+// though part of their data is available. Padding files never hold back a piece.
+func GetReadiness(info metainfo.Info, linkedFilePaths []string) (Readiness, error) {
+	if !info.HasV1() || info.PieceLength <= 0 {
+		return Readiness{}, errors.New("torrent has no v1 pieces")
+	}
+
+	linked := make(map[string]struct{}, len(linkedFilePaths))
+	for _, filePath := range linkedFilePaths {
+		linked[filePath] = struct{}{}
+	}
+
+	numPieces := info.NumPieces()
+	missing := make([]bool, numPieces)
+
+	for _, file := range info.UpvertedV1Files() {
+		if file.Length == 0 || strings.Contains(file.Attr, "p") {
+			continue
+		}
+
+		if _, ok := linked[file.DisplayPath(&info)]; ok {
+			continue
+		}
+
+		first := int(file.TorrentOffset / info.PieceLength)
+		last := int((file.TorrentOffset + file.Length - 1) / info.PieceLength)
+
+		for i := first; i <= last && i < numPieces; i++ {
+			missing[i] = true
+		}
+	}
+
+	readiness := Readiness{
+		TotalPieces: numPieces,
+		TotalSize:   info.TotalLength(),
+	}
+
+	for i := 0; i < numPieces; i++ {
+		if missing[i] {
+			continue
+		}
+
+		readiness.CompletePieces++
+		readiness.CompleteSize += info.Piece(i).V1Length()
+	}
+
+	if readiness.TotalSize > 0 {
+		readiness.Completion = float32(float64(readiness.CompleteSize) / float64(readiness.TotalSize))
+	}
+
+	return readiness, nil
+}
@@ -0,0 +1,164 @@
+// Copyright (c) 2023 - 2024, nuxen and the seasonpackarr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package torrents
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nuxencs/seasonpackarr/pkg/errors"
+
+	"github.com/anacrolix/torrent/metainfo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// buildTestTorrent creates a season pack with the given file sizes in a temporary directory and returns its info
+// together with the path of the pack. Every file is filled with a different byte so the pieces differ.
+func buildTestTorrent(t *testing.T, pieceLength int64, sizes ...int) (metainfo.Info, string) {
+	t.Helper()
+
+	packDir := filepath.Join(t.TempDir(), "Series.Title.S01.1080p.WEB-DL.DDP5.1.H.264-RlsGrp")
+	require.NoError(t, os.MkdirAll(packDir, 0o755))
+
+	for i, size := range sizes {
+		name := filepath.Join(packDir, []string{"E01.mkv", "E02.mkv", "E03.mkv", "E04.mkv"}[i])
+		require.NoError(t, os.WriteFile(name, bytes.Repeat([]byte{byte('a' + i)}, size), 0o644))
+	}
+
+	info := metainfo.Info{PieceLength: pieceLength}
+	require.NoError(t, info.BuildFromFilePath(packDir))
+
+	return info, packDir
+}
+
+func Test_filePieceRange(t *testing.T) {
+	info, _ := buildTestTorrent(t, 16, 40, 8, 50)
+	files := info.UpvertedV1Files()
+
+	tests := []struct {
+		name      string
+		file      metainfo.FileInfo
+		wantBegin int
+		wantEnd   int
+	}{
+		{name: "first_file", file: files[0], wantBegin: 0, wantEnd: 2},
+		{name: "file_within_one_piece", file: files[1], wantBegin: 3, wantEnd: 3},
+		{name: "last_file", file: files[2], wantBegin: 3, wantEnd: 7},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			begin, end := filePieceRange(&info, tt.file)
+			assert.Equal(t, tt.wantBegin, begin)
+			assert.Equal(t, tt.wantEnd, end)
+		})
+	}
+}
+
+func Test_samplePieces(t *testing.T) {
+	tests := []struct {
+		name    string
+		begin   int
+		end     int
+		samples int
+		want    []int
+	}{
+		{name: "all", begin: 2, end: 6, samples: 0, want: []int{2, 3, 4, 5}},
+		{name: "more_samples_than_pieces", begin: 2, end: 4, samples: 5, want: []int{2, 3}},
+		{name: "spread", begin: 0, end: 10, samples: 3, want: []int{0, 4, 9}},
+		{name: "single", begin: 0, end: 10, samples: 1, want: []int{5}},
+		{name: "empty", begin: 3, end: 3, samples: 2, want: []int{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, samplePieces(tt.begin, tt.end, tt.samples))
+		})
+	}
+}
+
+func Test_VerifyFilePieces(t *testing.T) {
+	info, packDir := buildTestTorrent(t, 16, 40, 8, 50)
+	torrentFilePath := "E03.mkv"
+
+	t.Run("verified", func(t *testing.T) {
+		verified, err := VerifyFilePieces(info, torrentFilePath, filepath.Join(packDir, "E03.mkv"), 0)
+		require.NoError(t, err)
+		assert.Equal(t, 4, verified)
+	})
+
+	t.Run("sampled", func(t *testing.T) {
+		verified, err := VerifyFilePieces(info, torrentFilePath, filepath.Join(packDir, "E03.mkv"), 2)
+		require.NoError(t, err)
+		assert.Equal(t, 2, verified)
+	})
+
+	t.Run("same_size_different_data", func(t *testing.T) {
+		otherFile := filepath.Join(t.TempDir(), "E03.mkv")
+		require.NoError(t, os.WriteFile(otherFile, bytes.Repeat([]byte{'z'}, 50), 0o644))
+
+		_, err := VerifyFilePieces(info, torrentFilePath, otherFile, 0)
+		assert.True(t, errors.Is(err, ErrPieceMismatch))
+	})
+
+	t.Run("file_not_in_torrent", func(t *testing.T) {
+		_, err := VerifyFilePieces(info, "E09.mkv", filepath.Join(packDir, "E03.mkv"), 0)
+		assert.Error(t, err)
+	})
+
+	t.Run("no_verifiable_pieces", func(t *testing.T) {
+		// E02 only lies within the boundary piece shared with E01 and E03
+		verified, err := VerifyFilePieces(info, "E02.mkv", filepath.Join(packDir, "E02.mkv"), 0)
+		assert.True(t, errors.Is(err, ErrNoVerifiablePieces))
+		assert.Zero(t, verified)
+	})
+}
+
+func Test_GetReadiness(t *testing.T) {
+	// pieces: 0-1 E01, 2 E01+E02+E03, 3-6 E03 with the last piece only 2 bytes long
+	info, _ := buildTestTorrent(t, 16, 40, 8, 50)
+
+	tests := []struct {
+		name        string
+		linkedFiles []string
+		want        Readiness
+	}{
+		{
+			name:        "all_files",
+			linkedFiles: []string{"E01.mkv", "E02.mkv", "E03.mkv"},
+			want: Readiness{
+				TotalPieces: 7, CompletePieces: 7, TotalSize: 98, CompleteSize: 98, Completion: 1,
+			},
+		},
+		{
+			name:        "missing_file_in_boundary_piece",
+			linkedFiles: []string{"E01.mkv", "E03.mkv"},
+			want: Readiness{
+				TotalPieces: 7, CompletePieces: 6, TotalSize: 98, CompleteSize: 82, Completion: float32(82.0 / 98.0),
+			},
+		},
+		{
+			name:        "missing_first_file",
+			linkedFiles: []string{"E02.mkv", "E03.mkv"},
+			want: Readiness{
+				TotalPieces: 7, CompletePieces: 4, TotalSize: 98, CompleteSize: 50, Completion: float32(50.0 / 98.0),
+			},
+		},
+		{
+			name:        "nothing_linked",
+			linkedFiles: nil,
+			want: Readiness{
+				TotalPieces: 7, CompletePieces: 0, TotalSize: 98, CompleteSize: 0, Completion: 0,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := GetReadiness(info, tt.linkedFiles)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -7,17 +7,28 @@ import (
 	"bytes"
 	"cmp"
 	"fmt"
-	"path/filepath"
 	"slices"
 
+	"github.com/nuxencs/seasonpackarr/internal/utils"
+
 	"github.com/anacrolix/torrent/metainfo"
 )
 
+type File struct {
+	Path string
+	Size int64
+}
+
 type Episode struct {
 	Path string
 	Size int64
 }
 
+type CompanionFile struct {
+	Path string
+	Size int64
+}
+
 func ParseInfoFromTorrentBytes(torrentBytes []byte) (metainfo.Info, error) {
 	metaInfo, err := metainfo.Load(bytes.NewReader(torrentBytes))
 	if err != nil {
@@ -27,29 +38,63 @@ func ParseInfoFromTorrentBytes(torrentBytes []byte) (metainfo.Info, error) {
 	return metaInfo.UnmarshalInfo()
 }
 
-func GetEpisodesFromTorrentInfo(info metainfo.Info) ([]Episode, error) {
+// GetInfoHashFromTorrentBytes returns the hex encoded v1 infohash of the torrent.
+func GetInfoHashFromTorrentBytes(torrentBytes []byte) (string, error) {
+	metaInfo, err := metainfo.Load(bytes.NewReader(torrentBytes))
+	if err != nil {
+		return "", err
+	}
+
+	return metaInfo.HashInfoBytes().HexString(), nil
+}
+
+func GetFilePathsFromTorrentInfo(info metainfo.Info) []string {
+	files := info.UpvertedFiles()
+	paths := make([]string, 0, len(files))
+
+	for _, file := range files {
+		paths = append(paths, file.DisplayPath(&info))
+	}
+
+	return paths
+}
+
+func GetFilesFromTorrentInfo(info metainfo.Info) []File {
+	files := info.UpvertedFiles()
+	torrentFiles := make([]File, 0, len(files))
+
+	for _, file := range files {
+		torrentFiles = append(torrentFiles, File{
+			Path: file.DisplayPath(&info),
+			Size: file.Length,
+		})
+	}
+
+	return torrentFiles
+}
+
+func GetEpisodesFromTorrentInfo(info metainfo.Info, extensions []string) ([]Episode, error) {
 	if !info.IsDir() {
 		return []Episode{}, fmt.Errorf("not a directory")
 	}
 
-	files := info.UpvertedFiles()
+	return GetEpisodes(GetFilesFromTorrentInfo(info), extensions)
+}
+
+// GetEpisodes returns the files with one of the given extensions that aren't samples, sorted by path.
+func GetEpisodes(files []File, extensions []string) ([]Episode, error) {
 	episodes := make([]Episode, 0, len(files))
 
 	for _, file := range files {
-		path := file.DisplayPath(&info)
-
-		if filepath.Ext(path) != ".mkv" {
+		if !utils.HasExtension(file.Path, extensions) || utils.IsSampleFile(file.Path) {
 			continue
 		}
 
-		episodes = append(episodes, Episode{
-			Path: path,
-			Size: file.Length,
-		})
+		episodes = append(episodes, Episode(file))
 	}
 
 	if len(episodes) == 0 {
-		return []Episode{}, fmt.Errorf("no .mkv files found")
+		return []Episode{}, fmt.Errorf("no files with extensions %v found", extensions)
 	}
 
 	if len(episodes) > 1 {
@@ -60,3 +105,30 @@ func GetEpisodesFromTorrentInfo(info metainfo.Info) ([]Episode, error) {
 
 	return episodes, nil
 }
+
+func GetCompanionFilesFromTorrentInfo(info metainfo.Info, isCompanionFile func(path string) bool) []CompanionFile {
+	if !info.IsDir() {
+		return []CompanionFile{}
+	}
+
+	return GetCompanionFiles(GetFilesFromTorrentInfo(info), isCompanionFile)
+}
+
+// GetCompanionFiles returns the files accepted by isCompanionFile, sorted by path.
+func GetCompanionFiles(files []File, isCompanionFile func(path string) bool) []CompanionFile {
+	companionFiles := make([]CompanionFile, 0)
+
+	for _, file := range files {
+		if !isCompanionFile(file.Path) {
+			continue
+		}
+
+		companionFiles = append(companionFiles, CompanionFile(file))
+	}
+
+	slices.SortStableFunc(companionFiles, func(a, b CompanionFile) int {
+		return cmp.Compare(a.Path, b.Path)
+	})
+
+	return companionFiles
+}
@@ -0,0 +1,76 @@
+// Copyright (c) 2023 - 2024, nuxen and the seasonpackarr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package torrents
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_GetEpisodes(t *testing.T) {
+	tests := []struct {
+		name       string
+		files      []File
+		extensions []string
+		want       []Episode
+		wantErr    bool
+	}{
+		{
+			name: "sorted_by_path",
+			files: []File{
+				{Path: "Series.Title.S01/Series.Title.S01E02.1080p.WEB-DL.DDP5.1.H.264-RlsGrp.mkv", Size: 2},
+				{Path: "Series.Title.S01/Series.Title.S01E01.1080p.WEB-DL.DDP5.1.H.264-RlsGrp.mkv", Size: 1},
+				{Path: "Series.Title.S01/Series.Title.S01.1080p.WEB-DL.DDP5.1.H.264-RlsGrp.nfo", Size: 3},
+			},
+			extensions: []string{"mkv"},
+			want: []Episode{
+				{Path: "Series.Title.S01/Series.Title.S01E01.1080p.WEB-DL.DDP5.1.H.264-RlsGrp.mkv", Size: 1},
+				{Path: "Series.Title.S01/Series.Title.S01E02.1080p.WEB-DL.DDP5.1.H.264-RlsGrp.mkv", Size: 2},
+			},
+		},
+		{
+			name: "mkv_sample_skipped",
+			files: []File{
+				{Path: "Series.Title.S01/Series.Title.S01E01.1080p.WEB-DL.DDP5.1.H.264-RlsGrp.mkv", Size: 1},
+				{Path: "Series.Title.S01/Series.Title.S01E01.1080p.WEB-DL.DDP5.1.H.264-RlsGrp-sample.mkv", Size: 2},
+			},
+			extensions: []string{"mkv"},
+			want: []Episode{
+				{Path: "Series.Title.S01/Series.Title.S01E01.1080p.WEB-DL.DDP5.1.H.264-RlsGrp.mkv", Size: 1},
+			},
+		},
+		{
+			name: "avi_sample_skipped",
+			files: []File{
+				{Path: "Series.Title.S01/Series.Title.S01E01.720p.HDTV.x264-RlsGrp.avi", Size: 1},
+				{Path: "Series.Title.S01/Series.Title.S01E01.720p.HDTV.x264-RlsGrp.sample.avi", Size: 2},
+			},
+			extensions: []string{"avi"},
+			want: []Episode{
+				{Path: "Series.Title.S01/Series.Title.S01E01.720p.HDTV.x264-RlsGrp.avi", Size: 1},
+			},
+		},
+		{
+			name: "only_samples",
+			files: []File{
+				{Path: "Series.Title.S01/Series.Title.S01E01.720p.HDTV.x264-RlsGrp-sample.avi", Size: 1},
+			},
+			extensions: []string{"avi"},
+			want:       []Episode{},
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := GetEpisodes(tt.files, tt.extensions)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,135 @@
+// Copyright (c) 2023 - 2024, nuxen and the seasonpackarr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package utils
+
+import (
+	"io/fs"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"syscall"
+
+	"github.com/nuxencs/seasonpackarr/pkg/errors"
+)
+
+// defaultDirMode is used for created directories if no mode is configured. It is still subject to the umask.
+const defaultDirMode fs.FileMode = 0o755
+
+// DirOptions control the permissions and ownership of the directories seasonpackarr creates.
+type DirOptions struct {
+	// Mode is applied regardless of the umask, zero keeps the default mode masked by the umask.
+	Mode fs.FileMode
+	// UID and GID change the owner and group, -1 keeps the ones of the seasonpackarr process.
+	UID int
+	GID int
+	// Setgid makes files and directories created inside inherit the group of the directory.
+	Setgid bool
+}
+
+// DefaultDirOptions keep the default mode and the owner and group of the seasonpackarr process.
+var DefaultDirOptions = DirOptions{UID: -1, GID: -1}
+
+// NewDirOptions parses an octal mode like "0775" and a user and group that are given as either name or numeric id.
+// Empty values keep the defaults.
+func NewDirOptions(dirMode, userName, groupName string, setgid bool) (DirOptions, error) {
+	opts := DefaultDirOptions
+	opts.Setgid = setgid
+
+	if len(dirMode) != 0 {
+		mode, err := strconv.ParseUint(dirMode, 8, 32)
+		if err != nil || mode > 0o777 {
+			return DirOptions{}, errors.New("invalid dir mode %q, expected an octal mode like \"0775\"", dirMode)
+		}
+		opts.Mode = fs.FileMode(mode)
+	}
+
+	if len(userName) != 0 {
+		uid, err := strconv.Atoi(userName)
+		if err != nil {
+			u, lookupErr := user.Lookup(userName)
+			if lookupErr != nil {
+				return DirOptions{}, errors.Wrap(lookupErr, "could not find user %q", userName)
+			}
+
+			if uid, err = strconv.Atoi(u.Uid); err != nil {
+				return DirOptions{}, errors.Wrap(err, "user %q has no numeric id", userName)
+			}
+		}
+		opts.UID = uid
+	}
+
+	if len(groupName) != 0 {
+		gid, err := strconv.Atoi(groupName)
+		if err != nil {
+			g, lookupErr := user.LookupGroup(groupName)
+			if lookupErr != nil {
+				return DirOptions{}, errors.Wrap(lookupErr, "could not find group %q", groupName)
+			}
+
+			if gid, err = strconv.Atoi(g.Gid); err != nil {
+				return DirOptions{}, errors.Wrap(err, "group %q has no numeric id", groupName)
+			}
+		}
+		opts.GID = gid
+	}
+
+	return opts, nil
+}
+
+// MkdirAll works like os.MkdirAll, but applies the options to every directory it creates. Existing directories are
+// left untouched.
+func MkdirAll(dirPath string, opts DirOptions) error {
+	if fi, err := os.Stat(dirPath); err == nil {
+		if !fi.IsDir() {
+			return &os.PathError{Op: "mkdir", Path: dirPath, Err: syscall.ENOTDIR}
+		}
+		return nil
+	}
+
+	if parent := filepath.Dir(dirPath); parent != dirPath {
+		if err := MkdirAll(parent, opts); err != nil {
+			return err
+		}
+	}
+
+	mode := opts.Mode
+	if mode == 0 {
+		mode = defaultDirMode
+	}
+
+	if err := os.Mkdir(dirPath, mode); err != nil {
+		// another request might have created it in the meantime
+		if errors.Is(err, fs.ErrExist) {
+			return nil
+		}
+		return err
+	}
+
+	// change the owner first, since a chown can clear the setgid bit
+	if err := opts.chown(dirPath); err != nil {
+		return err
+	}
+
+	if opts.Mode != 0 || opts.Setgid {
+		if opts.Setgid {
+			mode |= fs.ModeSetgid
+		}
+
+		if err := os.Chmod(dirPath, mode); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// chown changes the owner and group of a created file or directory if they are configured.
+func (o DirOptions) chown(filePath string) error {
+	if o.UID < 0 && o.GID < 0 {
+		return nil
+	}
+
+	return os.Lchown(filePath, o.UID, o.GID)
+}
@@ -0,0 +1,94 @@
+// Copyright (c) 2023 - 2024, nuxen and the seasonpackarr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+//go:build unix
+
+package utils
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_NewDirOptions(t *testing.T) {
+	tests := []struct {
+		name      string
+		dirMode   string
+		userName  string
+		groupName string
+		want      DirOptions
+		wantErr   bool
+	}{
+		{
+			name: "defaults",
+			want: DirOptions{UID: -1, GID: -1},
+		},
+		{
+			name:      "numeric",
+			dirMode:   "0775",
+			userName:  "1000",
+			groupName: "1001",
+			want:      DirOptions{Mode: 0o775, UID: 1000, GID: 1001},
+		},
+		{
+			name:    "mode_without_leading_zero",
+			dirMode: "770",
+			want:    DirOptions{Mode: 0o770, UID: -1, GID: -1},
+		},
+		{
+			name:      "names",
+			userName:  "root",
+			groupName: "root",
+			want:      DirOptions{UID: 0, GID: 0},
+		},
+		{name: "invalid_mode", dirMode: "rwxr-xr-x", wantErr: true},
+		{name: "mode_out_of_range", dirMode: "7777", wantErr: true},
+		{name: "unknown_user", userName: "seasonpackarr-unknown-user", wantErr: true},
+		{name: "unknown_group", groupName: "seasonpackarr-unknown-group", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewDirOptions(tt.dirMode, tt.userName, tt.groupName, false)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_MkdirAll(t *testing.T) {
+	root := t.TempDir()
+	dirPath := filepath.Join(root, "Series.Title.S01", "Subs")
+
+	opts := DirOptions{Mode: 0o770, UID: os.Getuid(), GID: os.Getgid(), Setgid: true}
+	require.NoError(t, MkdirAll(dirPath, opts))
+
+	for _, p := range []string{filepath.Join(root, "Series.Title.S01"), dirPath} {
+		fi, err := os.Stat(p)
+		require.NoError(t, err)
+
+		assert.Equal(t, fs.FileMode(0o770), fi.Mode().Perm(), p)
+		assert.NotZero(t, fi.Mode()&fs.ModeSetgid, p)
+
+		stat, ok := fi.Sys().(*syscall.Stat_t)
+		require.True(t, ok)
+		assert.Equal(t, uint32(os.Getgid()), stat.Gid, p)
+	}
+
+	// existing directories are left untouched
+	rootInfo, err := os.Stat(root)
+	require.NoError(t, err)
+	assert.Zero(t, rootInfo.Mode()&fs.ModeSetgid)
+
+	assert.NoError(t, MkdirAll(dirPath, opts))
+}
@@ -0,0 +1,214 @@
+// Copyright (c) 2023 - 2024, nuxen and the seasonpackarr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package utils
+
+import (
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/nuxencs/seasonpackarr/internal/domain"
+	"github.com/nuxencs/seasonpackarr/pkg/errors"
+
+	"github.com/moistari/rls"
+)
+
+var ErrUnsafePath = errors.Sentinel("unsafe path")
+
+// HasExtension reports whether the file at path has one of the given extensions. Extensions are compared
+// case-insensitively and can be given with or without a leading dot.
+func HasExtension(path string, extensions []string) bool {
+	ext := strings.TrimPrefix(filepath.Ext(path), ".")
+	if len(ext) == 0 {
+		return false
+	}
+
+	for _, extension := range extensions {
+		if strings.EqualFold(strings.TrimPrefix(extension, "."), ext) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsSampleFile reports whether the file at path is a sample, which is released with the group name "sample".
+func IsSampleFile(path string) bool {
+	return rls.MustNormalize(rls.ParseString(filepath.Base(path)).Group) == "sample"
+}
+
+// MatchesGlobs reports whether the file name or the whole slash separated path of a file matches one of
+// the given globs. Globs are matched case-insensitively.
+func MatchesGlobs(filePath string, globs []string) bool {
+	slashPath := strings.ToLower(filepath.ToSlash(filePath))
+
+	for _, glob := range globs {
+		glob = strings.ToLower(glob)
+
+		if ok, _ := path.Match(glob, path.Base(slashPath)); ok {
+			return true
+		}
+
+		if ok, _ := path.Match(glob, slashPath); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isUnsafeElement reports whether a path element taken from a torrent or an announce could escape the directory
+// it is joined to. Both slash and backslash are treated as separators, since the element may come from any OS.
+func isUnsafeElement(elem string) bool {
+	if strings.ContainsRune(elem, 0) || filepath.IsAbs(elem) || len(filepath.VolumeName(elem)) != 0 ||
+		strings.HasPrefix(elem, "/") || strings.HasPrefix(elem, `\`) {
+		return true
+	}
+
+	// drive letters like C: are only detected by filepath.VolumeName on windows
+	if len(elem) >= 2 && elem[1] == ':' {
+		return true
+	}
+
+	for _, part := range strings.FieldsFunc(elem, func(r rune) bool { return r == '/' || r == '\\' }) {
+		if part == ".." {
+			return true
+		}
+	}
+
+	return false
+}
+
+// SafeJoin joins the relative elements to root and makes sure the result is located below root. Absolute elements,
+// parent directory references and results that resolve to root itself are rejected with ErrUnsafePath.
+func SafeJoin(root string, elems ...string) (string, error) {
+	for _, elem := range elems {
+		if isUnsafeElement(elem) {
+			return "", errors.Wrap(ErrUnsafePath, "%q", elem)
+		}
+	}
+
+	root = filepath.Clean(root)
+	joined := filepath.Join(append([]string{root}, elems...)...)
+
+	rel, err := filepath.Rel(root, joined)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", errors.Wrap(ErrUnsafePath, "%q is not below %q", joined, root)
+	}
+
+	return joined, nil
+}
+
+// PackTargetPath returns the path of a file of the season pack inside the pre import path. The pack name has to be
+// a single path element and the file path has to stay inside the pack folder. An empty file path returns the pack
+// folder itself.
+func PackTargetPath(preImportPath, packName, filePath string) (string, error) {
+	if strings.ContainsAny(packName, `/\`) {
+		return "", errors.Wrap(ErrUnsafePath, "pack name %q contains a path separator", packName)
+	}
+
+	packDir, err := SafeJoin(preImportPath, packName)
+	if err != nil {
+		return "", err
+	}
+
+	if len(filePath) == 0 {
+		return packDir, nil
+	}
+
+	return SafeJoin(packDir, filePath)
+}
+
+// cleanRemotePath cleans a path reported by the client. Backslashes are treated as separators, since the client may
+// run on another OS.
+func cleanRemotePath(remotePath string) string {
+	return path.Clean(strings.ReplaceAll(remotePath, `\`, "/"))
+}
+
+// HasRemotePathPrefix reports whether the path reported by the client equals the prefix or is below it. Prefixes only
+// match whole path elements.
+func HasRemotePathPrefix(remotePath, prefix string) bool {
+	slashPath, slashPrefix := cleanRemotePath(remotePath), cleanRemotePath(prefix)
+
+	return slashPath == slashPrefix || strings.HasPrefix(slashPath, strings.TrimSuffix(slashPrefix, "/")+"/")
+}
+
+// MapRemotePath translates a path reported by the client to the local path using the mapping with the longest
+// matching remote prefix. Prefixes only match whole path elements and paths without a matching mapping are returned
+// unchanged.
+func MapRemotePath(remotePath string, mappings []domain.PathMapping) string {
+	slashPath := cleanRemotePath(remotePath)
+
+	var (
+		bestRemote string
+		bestLocal  string
+		bestLen    = -1
+	)
+
+	for _, mapping := range mappings {
+		if !HasRemotePathPrefix(slashPath, mapping.Remote) {
+			continue
+		}
+
+		remote := cleanRemotePath(mapping.Remote)
+
+		if len(remote) > bestLen {
+			bestRemote, bestLocal, bestLen = remote, mapping.Local, len(remote)
+		}
+	}
+
+	if bestLen < 0 {
+		return remotePath
+	}
+
+	rel := strings.TrimPrefix(strings.TrimPrefix(slashPath, bestRemote), "/")
+
+	return filepath.Join(bestLocal, filepath.FromSlash(rel))
+}
+
+// MapLocalPath translates a local path to the path the client sees using the mapping with the longest matching local
+// prefix. It is the reverse of MapRemotePath and keeps the separator style of the remote path.
+func MapLocalPath(localPath string, mappings []domain.PathMapping) string {
+	cleanPath := filepath.Clean(localPath)
+	sep := string(filepath.Separator)
+
+	var (
+		bestRemote string
+		bestLocal  string
+		bestLen    = -1
+	)
+
+	for _, mapping := range mappings {
+		local := filepath.Clean(mapping.Local)
+
+		if cleanPath != local && !strings.HasPrefix(cleanPath, strings.TrimSuffix(local, sep)+sep) {
+			continue
+		}
+
+		if len(local) > bestLen {
+			bestRemote, bestLocal, bestLen = mapping.Remote, local, len(local)
+		}
+	}
+
+	if bestLen < 0 {
+		return localPath
+	}
+
+	rel := filepath.ToSlash(strings.TrimPrefix(strings.TrimPrefix(cleanPath, bestLocal), sep))
+	remote := strings.TrimRight(bestRemote, `/\`)
+
+	if len(rel) == 0 {
+		if len(remote) == 0 {
+			return bestRemote
+		}
+		return remote
+	}
+
+	// remote paths with backslashes only belong to a client running on windows
+	if strings.Contains(remote, `\`) && !strings.Contains(remote, "/") {
+		return remote + `\` + strings.ReplaceAll(rel, "/", `\`)
+	}
+
+	return remote + "/" + rel
+}
@@ -0,0 +1,286 @@
+// Copyright (c) 2023 - 2024, nuxen and the seasonpackarr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package utils
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nuxencs/seasonpackarr/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_HasExtension(t *testing.T) {
+	tests := []struct {
+		name       string
+		path       string
+		extensions []string
+		want       bool
+	}{
+		{
+			name:       "mkv",
+			path:       "Series.Title.S01E01.1080p.WEB-DL.DDP5.1.H.264-RlsGrp.mkv",
+			extensions: []string{"mkv"},
+			want:       true,
+		},
+		{
+			name:       "mp4_not_allowed",
+			path:       "Series.Title.S01E01.1080p.WEB-DL.DDP5.1.H.264-RlsGrp.mp4",
+			extensions: []string{"mkv"},
+			want:       false,
+		},
+		{
+			name:       "mp4_allowed",
+			path:       "Series.Title.S01E01.1080p.WEB-DL.DDP5.1.H.264-RlsGrp.mp4",
+			extensions: []string{"mkv", "mp4"},
+			want:       true,
+		},
+		{
+			name:       "uppercase_with_dot",
+			path:       "Series Title S01/Series.Title.S01E01.720p.HDTV.x264-RlsGrp.AVI",
+			extensions: []string{".avi"},
+			want:       true,
+		},
+		{
+			name:       "no_extension",
+			path:       "Series.Title.S01E01.1080p.WEB-DL.DDP5.1.H.264-RlsGrp",
+			extensions: []string{"mkv"},
+			want:       false,
+		},
+		{
+			name:       "no_extensions_allowed",
+			path:       "Series.Title.S01E01.1080p.WEB-DL.DDP5.1.H.264-RlsGrp.mkv",
+			extensions: []string{},
+			want:       false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equalf(t, tt.want, HasExtension(tt.path, tt.extensions), "HasExtension(%v, %v)", tt.path, tt.extensions)
+		})
+	}
+}
+
+func Test_IsSampleFile(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		want bool
+	}{
+		{
+			name: "sample_with_dash",
+			path: "Series.Title.S01E01.1080p.WEB-DL.DDP5.1.H.264-RlsGrp-sample.mkv",
+			want: true,
+		},
+		{
+			name: "sample_with_dot_in_folder",
+			path: "Series Title S01/Series.Title.S01E01.720p.HDTV.x264-RlsGrp.sample.avi",
+			want: true,
+		},
+		{
+			name: "episode",
+			path: "Series.Title.S01E01.1080p.WEB-DL.DDP5.1.H.264-RlsGrp.mkv",
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equalf(t, tt.want, IsSampleFile(tt.path), "IsSampleFile(%v)", tt.path)
+		})
+	}
+}
+
+func Test_MatchesGlobs(t *testing.T) {
+	tests := []struct {
+		name     string
+		filePath string
+		globs    []string
+		want     bool
+	}{
+		{
+			name:     "file_name",
+			filePath: "Series.Title.S01E01.1080p.WEB-DL.DDP5.1.H.264-RlsGrp.srt",
+			globs:    []string{"*.srt"},
+			want:     true,
+		},
+		{
+			name:     "file_name_in_subfolder",
+			filePath: "Subs/English.SRT",
+			globs:    []string{"*.srt"},
+			want:     true,
+		},
+		{
+			name:     "whole_path",
+			filePath: "Subs/English.srt",
+			globs:    []string{"subs/*"},
+			want:     true,
+		},
+		{
+			name:     "no_match",
+			filePath: "Series.Title.S01E01.1080p.WEB-DL.DDP5.1.H.264-RlsGrp.nfo",
+			globs:    []string{"*.srt", "*.ass"},
+			want:     false,
+		},
+		{
+			name:     "no_globs",
+			filePath: "Series.Title.S01E01.1080p.WEB-DL.DDP5.1.H.264-RlsGrp.nfo",
+			globs:    []string{},
+			want:     false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equalf(t, tt.want, MatchesGlobs(tt.filePath, tt.globs), "MatchesGlobs(%v, %v)", tt.filePath, tt.globs)
+		})
+	}
+}
+
+func Test_PackTargetPath(t *testing.T) {
+	tests := []struct {
+		name     string
+		packName string
+		filePath string
+		want     string
+		wantErr  bool
+	}{
+		{
+			name:     "episode",
+			packName: "Series.Title.S01.1080p.WEB-DL.DDP5.1.H.264-RlsGrp",
+			filePath: "Series.Title.S01E01.1080p.WEB-DL.DDP5.1.H.264-RlsGrp.mkv",
+			want:     "/data/pre/Series.Title.S01.1080p.WEB-DL.DDP5.1.H.264-RlsGrp/Series.Title.S01E01.1080p.WEB-DL.DDP5.1.H.264-RlsGrp.mkv",
+		},
+		{
+			name:     "subfolder",
+			packName: "Series.Title.S01.1080p.WEB-DL.DDP5.1.H.264-RlsGrp",
+			filePath: "Subs/Series.Title.S01E01/English.srt",
+			want:     "/data/pre/Series.Title.S01.1080p.WEB-DL.DDP5.1.H.264-RlsGrp/Subs/Series.Title.S01E01/English.srt",
+		},
+		{
+			name:     "pack_folder",
+			packName: "Series.Title.S01.1080p.WEB-DL.DDP5.1.H.264-RlsGrp",
+			want:     "/data/pre/Series.Title.S01.1080p.WEB-DL.DDP5.1.H.264-RlsGrp",
+		},
+		{
+			name:     "dots_in_name",
+			packName: "Series.Title...S01",
+			filePath: "..Series.Title.S01E01.mkv",
+			want:     "/data/pre/Series.Title...S01/..Series.Title.S01E01.mkv",
+		},
+		{name: "parent_pack_name", packName: "..", wantErr: true},
+		{name: "current_pack_name", packName: ".", wantErr: true},
+		{name: "empty_pack_name", packName: "", wantErr: true},
+		{name: "pack_name_with_separator", packName: "Series/../../etc", wantErr: true},
+		{name: "pack_name_with_backslash", packName: `Series\..\..\etc`, wantErr: true},
+		{name: "absolute_file", packName: "Series", filePath: "/etc/passwd", wantErr: true},
+		{name: "parent_file", packName: "Series", filePath: "../../etc/passwd", wantErr: true},
+		{name: "nested_parent_file", packName: "Series", filePath: "Subs/../../other/file.mkv", wantErr: true},
+		{name: "backslash_parent_file", packName: "Series", filePath: `Subs\..\..\file.mkv`, wantErr: true},
+		{name: "drive_letter_file", packName: "Series", filePath: `C:\Windows\file.mkv`, wantErr: true},
+		{name: "nul_file", packName: "Series", filePath: "file\x00.mkv", wantErr: true},
+		{name: "file_resolving_to_pack", packName: "Series", filePath: "Subs/..", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := PackTargetPath("/data/pre", tt.packName, tt.filePath)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrUnsafePath)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_MapRemotePath(t *testing.T) {
+	mappings := []domain.PathMapping{
+		{Remote: "/downloads", Local: "/data/torrents"},
+		{Remote: "/downloads/tv/", Local: "/mnt/tv"},
+		{Remote: `D:\Torrents`, Local: "/data/windows"},
+	}
+
+	tests := []struct {
+		name       string
+		remotePath string
+		want       string
+	}{
+		{name: "mapped", remotePath: "/downloads/movies", want: "/data/torrents/movies"},
+		{name: "longest_prefix", remotePath: "/downloads/tv/Series.Title", want: "/mnt/tv/Series.Title"},
+		{name: "exact", remotePath: "/downloads", want: "/data/torrents"},
+		{name: "trailing_slash", remotePath: "/downloads/tv/", want: "/mnt/tv"},
+		{name: "partial_element", remotePath: "/downloads2/tv", want: "/downloads2/tv"},
+		{name: "unmapped", remotePath: "/other/tv", want: "/other/tv"},
+		{name: "windows", remotePath: `D:\Torrents\tv`, want: "/data/windows/tv"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equalf(t, tt.want, MapRemotePath(tt.remotePath, mappings), "MapRemotePath(%v)", tt.remotePath)
+		})
+	}
+}
+
+func Test_MapLocalPath(t *testing.T) {
+	mappings := []domain.PathMapping{
+		{Remote: "/downloads", Local: "/data/torrents"},
+		{Remote: "/tv/", Local: "/data/torrents/tv"},
+		{Remote: `D:\Torrents`, Local: "/data/windows"},
+		{Remote: "/", Local: "/mnt/root"},
+	}
+
+	tests := []struct {
+		name      string
+		localPath string
+		want      string
+	}{
+		{name: "mapped", localPath: "/data/torrents/movies", want: "/downloads/movies"},
+		{name: "longest_prefix", localPath: "/data/torrents/tv/pre", want: "/tv/pre"},
+		{name: "exact", localPath: "/data/torrents/tv", want: "/tv"},
+		{name: "partial_element", localPath: "/data/torrents2/tv", want: "/data/torrents2/tv"},
+		{name: "unmapped", localPath: "/other/tv", want: "/other/tv"},
+		{name: "windows", localPath: "/data/windows/tv/pre", want: `D:\Torrents\tv\pre`},
+		{name: "root", localPath: "/mnt/root/pre", want: "/pre"},
+		{name: "root_exact", localPath: "/mnt/root", want: "/"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equalf(t, tt.want, MapLocalPath(tt.localPath, mappings), "MapLocalPath(%v)", tt.localPath)
+		})
+	}
+}
+
+func Fuzz_PackTargetPath(f *testing.F) {
+	f.Add("Series.Title.S01.1080p.WEB-DL.DDP5.1.H.264-RlsGrp", "Series.Title.S01E01.mkv")
+	f.Add("..", "file.mkv")
+	f.Add("Series", "../../etc/passwd")
+	f.Add("Series", "Subs/../../file.mkv")
+	f.Add("Series", `..\..\file.mkv`)
+	f.Add("Series", "/etc/passwd")
+	f.Add("Series", "./././.")
+
+	const preImportPath = "/data/pre"
+
+	f.Fuzz(func(t *testing.T, packName, filePath string) {
+		got, err := PackTargetPath(preImportPath, packName, filePath)
+		if err != nil {
+			return
+		}
+
+		packDir := filepath.Join(preImportPath, packName)
+		if filepath.Dir(packDir) != preImportPath {
+			t.Fatalf("pack folder %q is not directly below %q", packDir, preImportPath)
+		}
+
+		rel, err := filepath.Rel(packDir, got)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, "../") {
+			t.Fatalf("target %q escapes pack folder %q", got, packDir)
+		}
+
+		if len(filePath) != 0 && rel == "." {
+			t.Fatalf("target %q resolves to pack folder %q", got, packDir)
+		}
+	})
+}
@@ -5,39 +5,41 @@ package utils
 
 import (
 	"fmt"
-	"regexp"
+	"slices"
 	"strings"
 
+	"github.com/nuxencs/seasonpackarr/internal/domain"
+
 	"github.com/moistari/rls"
 )
 
-func GetFormattedTitle(r rls.Release) string {
+func GetFormattedTitle(r rls.Release, withLanguage bool) string {
 	s := fmt.Sprintf("%s%d%d", rls.MustNormalize(r.Title), r.Year, r.Series)
 
+	// append the sorted languages so releases with different languages end up with different titles
+	if withLanguage && len(r.Language) != 0 {
+		languages := make([]string, 0, len(r.Language))
+		for _, language := range r.Language {
+			languages = append(languages, rls.MustNormalize(language))
+		}
+		slices.Sort(languages)
+
+		s += "|" + strings.Join(languages, " ")
+	}
+
 	return s
 }
 
+// FormatSeasonPackTitle formats the season pack folder name with the default folder naming rules.
 func FormatSeasonPackTitle(packName string) string {
-	// regex for groups that don't need the folder name to be adjusted
-	reIgnoredRlsGrps := regexp.MustCompile(`(?i)^(ZR)$`)
-
-	reIllegal := regexp.MustCompile(`(?i)[\\/:"*?<>|]`)
-	reAudio := regexp.MustCompile(`(?i)(AAC|DDP)\.(\d\.\d)`)
-	reDots := regexp.MustCompile(`(?i)\.+`)
-
-	r := rls.ParseString(packName)
-
-	// check if RlsGrp of release is in ignore regex
-	if !reIgnoredRlsGrps.MatchString(r.Group) {
-		// remove illegal characters
-		packName = reIllegal.ReplaceAllString(packName, "")
-		// replace spaces with periods
-		packName = strings.ReplaceAll(packName, " ", ".")
-		// replace wrong audio naming
-		packName = reAudio.ReplaceAllString(packName, "$1$2")
-		// replace multiple dots with only one
-		packName = reDots.ReplaceAllString(packName, ".")
-	}
+	f, _ := NewFolderNamer(domain.FolderNaming{
+		Rules:        domain.DefaultFolderRules,
+		ExemptGroups: domain.DefaultExemptGroups,
+	}, "")
+
+	// the default rules don't use a template, so formatting can't fail
+	packName, _ = f.Format(packName)
+
 	return packName
 }
 
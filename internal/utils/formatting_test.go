@@ -12,9 +12,10 @@ import (
 
 func Test_GetFormattedTitle(t *testing.T) {
 	tests := []struct {
-		name     string
-		packName string
-		want     string
+		name         string
+		packName     string
+		withLanguage bool
+		want         string
 	}{
 		{
 			name:     "pack_1",
@@ -86,11 +87,28 @@ func Test_GetFormattedTitle(t *testing.T) {
 			packName: "The Continental 2023 S01 2160p PCOK WEB-DL DDP5.1 Atmos HDR DV H.265-FLUX",
 			want:     "the continental20231",
 		},
+		{
+			name:     "pack_15",
+			packName: "Dark S01 GERMAN DL 1080p WEB H264-RlsGrp",
+			want:     "dark01",
+		},
+		{
+			name:         "pack_16",
+			packName:     "Dark S01 GERMAN DL 1080p WEB H264-RlsGrp",
+			withLanguage: true,
+			want:         "dark01|dl german",
+		},
+		{
+			name:         "pack_17",
+			packName:     "Dark S01 1080p WEB H264-RlsGrp",
+			withLanguage: true,
+			want:         "dark01",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			r := rls.ParseString(tt.packName)
-			assert.Equalf(t, tt.want, GetFormattedTitle(r), "FormatSeasonPackTitle(%s)", tt.packName)
+			assert.Equalf(t, tt.want, GetFormattedTitle(r, tt.withLanguage), "GetFormattedTitle(%s, %t)", tt.packName, tt.withLanguage)
 		})
 	}
 }
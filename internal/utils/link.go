@@ -0,0 +1,250 @@
+// Copyright (c) 2023 - 2024, nuxen and the seasonpackarr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package utils
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/nuxencs/seasonpackarr/internal/domain"
+	"github.com/nuxencs/seasonpackarr/pkg/errors"
+)
+
+var (
+	ErrCrossDevice      = errors.Sentinel("source and target are on different devices")
+	ErrUnknownStrategy  = errors.Sentinel("unknown link strategy")
+	ErrNoLinkStrategies = errors.Sentinel("no link strategies")
+	ErrLinkConflict     = errors.Sentinel("target already exists with different content")
+)
+
+// LinkExisting is reported instead of a link strategy if the target already existed with the same content.
+const LinkExisting = "existing"
+
+type LinkOptions struct {
+	// Strategies are tried in order until one of them succeeds.
+	Strategies []string
+	// Overwrite replaces existing targets with different content.
+	Overwrite bool
+	// Dirs are applied to the directories that are created for the target. The owner and group are applied to
+	// copies and reflinks as well, since they don't share the file with the client.
+	Dirs DirOptions
+}
+
+// CreateLink links the source path to the target path with the first of the strategies that succeeds and returns
+// the strategy that was used. Hardlinks and reflinks are skipped right away if source and target aren't on the
+// same device.
+//
+// A target that already exists is fine if it is the same file or has the same content as the source, so retries
+// and the same pack announced on multiple trackers don't fail. Any other existing target is only replaced if
+// Overwrite is set and reported as ErrLinkConflict otherwise.
+func CreateLink(srcPath, trgPath string, opts LinkOptions) (string, error) {
+	if len(opts.Strategies) == 0 {
+		return "", ErrNoLinkStrategies
+	}
+
+	trgDir := filepath.Dir(trgPath)
+
+	// create the target directory if it doesn't exist
+	if err := MkdirAll(trgDir, opts.Dirs); err != nil {
+		return "", err
+	}
+
+	same, err := sameDevice(srcPath, trgDir)
+	if err != nil {
+		return "", err
+	}
+
+	strategy, err := linkFile(srcPath, trgPath, opts, same)
+	if !errors.Is(err, fs.ErrExist) {
+		return strategy, err
+	}
+
+	identical, err := isIdentical(srcPath, trgPath)
+	if err != nil {
+		return "", err
+	}
+
+	if identical {
+		return LinkExisting, nil
+	}
+
+	if !opts.Overwrite {
+		return "", errors.Wrap(ErrLinkConflict, "%s", trgPath)
+	}
+
+	if err = os.Remove(trgPath); err != nil {
+		return "", err
+	}
+
+	return linkFile(srcPath, trgPath, opts, same)
+}
+
+// linkFile tries the strategies in order. A target that already exists ends the fallback.
+func linkFile(srcPath, trgPath string, opts LinkOptions, sameDevice bool) (string, error) {
+	var linkErr error
+
+	for _, strategy := range opts.Strategies {
+		var err error
+
+		switch strategy {
+		case domain.LinkStrategyHardlink:
+			if !sameDevice {
+				err = ErrCrossDevice
+				break
+			}
+			err = os.Link(srcPath, trgPath)
+		case domain.LinkStrategyReflink:
+			if !sameDevice {
+				err = ErrCrossDevice
+				break
+			}
+			err = reflinkFile(srcPath, trgPath)
+		case domain.LinkStrategySymlink:
+			err = symlinkFile(srcPath, trgPath)
+		case domain.LinkStrategyCopy:
+			err = copyFile(srcPath, trgPath)
+		default:
+			err = errors.Wrap(ErrUnknownStrategy, "%q", strategy)
+		}
+
+		// copies and reflinks are new files, so they get the configured owner as well
+		if err == nil && (strategy == domain.LinkStrategyReflink || strategy == domain.LinkStrategyCopy) {
+			if err = opts.Dirs.chown(trgPath); err != nil {
+				os.Remove(trgPath)
+			}
+		}
+
+		if err == nil {
+			return strategy, nil
+		}
+
+		if errors.Is(err, fs.ErrExist) {
+			return "", err
+		}
+
+		linkErr = errors.Wrap(err, "%s failed", strategy)
+	}
+
+	return "", linkErr
+}
+
+// isIdentical reports whether the target is the same file as the source, either as hardlink or symlink, or has the
+// same content.
+func isIdentical(srcPath, trgPath string) (bool, error) {
+	srcInfo, err := os.Stat(srcPath)
+	if err != nil {
+		return false, err
+	}
+
+	trgInfo, err := os.Stat(trgPath)
+	if err != nil {
+		// dangling symlinks and the like can't be identical
+		if errors.Is(err, fs.ErrNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	if os.SameFile(srcInfo, trgInfo) {
+		return true, nil
+	}
+
+	if !trgInfo.Mode().IsRegular() || srcInfo.Size() != trgInfo.Size() {
+		return false, nil
+	}
+
+	return sameContent(srcPath, trgPath)
+}
+
+// sameContent compares both files chunk by chunk and stops at the first difference.
+func sameContent(srcPath, trgPath string) (bool, error) {
+	src, err := os.Open(srcPath)
+	if err != nil {
+		return false, err
+	}
+	defer src.Close()
+
+	trg, err := os.Open(trgPath)
+	if err != nil {
+		return false, err
+	}
+	defer trg.Close()
+
+	srcBuf := make([]byte, 1<<20)
+	trgBuf := make([]byte, 1<<20)
+
+	for {
+		n, srcErr := io.ReadFull(src, srcBuf)
+		m, trgErr := io.ReadFull(trg, trgBuf)
+
+		if n != m || !bytes.Equal(srcBuf[:n], trgBuf[:m]) {
+			return false, nil
+		}
+
+		srcDone := errors.Is(srcErr, io.EOF) || errors.Is(srcErr, io.ErrUnexpectedEOF)
+		trgDone := errors.Is(trgErr, io.EOF) || errors.Is(trgErr, io.ErrUnexpectedEOF)
+
+		switch {
+		case srcErr != nil && !srcDone:
+			return false, srcErr
+		case trgErr != nil && !trgDone:
+			return false, trgErr
+		case srcDone || trgDone:
+			return srcDone == trgDone, nil
+		}
+	}
+}
+
+// symlinkFile creates an absolute symlink, so it keeps working no matter where the target folder is moved to.
+func symlinkFile(srcPath, trgPath string) error {
+	absPath, err := filepath.Abs(srcPath)
+	if err != nil {
+		return err
+	}
+
+	return os.Symlink(absPath, trgPath)
+}
+
+// copyFile copies the content and permissions of the source file. A partially written target is removed again.
+func copyFile(srcPath, trgPath string) error {
+	return writeFile(srcPath, trgPath, func(src, trg *os.File) error {
+		_, err := io.Copy(trg, src)
+		return err
+	})
+}
+
+// writeFile creates the target file exclusively and fills it from the source file with fill.
+func writeFile(srcPath, trgPath string, fill func(src, trg *os.File) error) error {
+	src, err := os.Open(srcPath)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	srcInfo, err := src.Stat()
+	if err != nil {
+		return err
+	}
+
+	trg, err := os.OpenFile(trgPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, srcInfo.Mode().Perm())
+	if err != nil {
+		return err
+	}
+
+	if err = fill(src, trg); err != nil {
+		trg.Close()
+		os.Remove(trgPath)
+		return err
+	}
+
+	if err = trg.Close(); err != nil {
+		os.Remove(trgPath)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,33 @@
+// Copyright (c) 2023 - 2024, nuxen and the seasonpackarr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package utils
+
+import (
+	"os"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// sameDevice reports whether the source file and the target directory are on the same device.
+func sameDevice(srcPath, trgDir string) (bool, error) {
+	var srcStat, trgStat syscall.Stat_t
+
+	if err := syscall.Stat(srcPath, &srcStat); err != nil {
+		return false, &os.PathError{Op: "stat", Path: srcPath, Err: err}
+	}
+
+	if err := syscall.Stat(trgDir, &trgStat); err != nil {
+		return false, &os.PathError{Op: "stat", Path: trgDir, Err: err}
+	}
+
+	return srcStat.Dev == trgStat.Dev, nil
+}
+
+// reflinkFile clones the source file with FICLONE, which shares the data blocks on filesystems like btrfs and XFS.
+func reflinkFile(srcPath, trgPath string) error {
+	return writeFile(srcPath, trgPath, func(src, trg *os.File) error {
+		return unix.IoctlFileClone(int(trg.Fd()), int(src.Fd()))
+	})
+}
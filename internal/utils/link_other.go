@@ -0,0 +1,25 @@
+// Copyright (c) 2023 - 2024, nuxen and the seasonpackarr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+//go:build !linux
+
+package utils
+
+import (
+	"errors"
+	"os"
+)
+
+// sameDevice can't tell the devices apart on this platform, so hardlinks are always attempted.
+func sameDevice(srcPath, trgDir string) (bool, error) {
+	if _, err := os.Stat(srcPath); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// reflinkFile isn't supported on this platform.
+func reflinkFile(srcPath, trgPath string) error {
+	return errors.ErrUnsupported
+}
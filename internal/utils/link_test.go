@@ -0,0 +1,131 @@
+// Copyright (c) 2023 - 2024, nuxen and the seasonpackarr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nuxencs/seasonpackarr/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_CreateLink(t *testing.T) {
+	tests := []struct {
+		name         string
+		strategies   []string
+		existing     string
+		overwrite    bool
+		wantStrategy string
+		wantErr      error
+	}{
+		{
+			name:         "hardlink",
+			strategies:   []string{domain.LinkStrategyHardlink},
+			wantStrategy: domain.LinkStrategyHardlink,
+		},
+		{
+			name:         "symlink",
+			strategies:   []string{domain.LinkStrategySymlink},
+			wantStrategy: domain.LinkStrategySymlink,
+		},
+		{
+			name:         "copy",
+			strategies:   []string{domain.LinkStrategyCopy},
+			wantStrategy: domain.LinkStrategyCopy,
+		},
+		{
+			name:         "fallback_after_unknown",
+			strategies:   []string{"unknown", domain.LinkStrategyCopy},
+			wantStrategy: domain.LinkStrategyCopy,
+		},
+		{
+			name:       "unknown",
+			strategies: []string{"unknown"},
+			wantErr:    ErrUnknownStrategy,
+		},
+		{
+			name:    "no_strategies",
+			wantErr: ErrNoLinkStrategies,
+		},
+		{
+			name:         "existing_identical_target",
+			strategies:   []string{domain.LinkStrategyHardlink},
+			existing:     "episode",
+			wantStrategy: LinkExisting,
+		},
+		{
+			name:       "existing_conflicting_target",
+			strategies: []string{domain.LinkStrategyHardlink, domain.LinkStrategyCopy},
+			existing:   "other",
+			wantErr:    ErrLinkConflict,
+		},
+		{
+			name:       "existing_conflicting_target_same_size",
+			strategies: []string{domain.LinkStrategyHardlink},
+			existing:   "epinose",
+			wantErr:    ErrLinkConflict,
+		},
+		{
+			name:         "overwrite_conflicting_target",
+			strategies:   []string{domain.LinkStrategyHardlink},
+			existing:     "other",
+			overwrite:    true,
+			wantStrategy: domain.LinkStrategyHardlink,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			srcPath := filepath.Join(dir, "src", "Series.Title.S01E01.mkv")
+			trgPath := filepath.Join(dir, "pre", "Series.Title.S01", "Series.Title.S01E01.mkv")
+
+			require.NoError(t, os.MkdirAll(filepath.Dir(srcPath), 0o755))
+			require.NoError(t, os.WriteFile(srcPath, []byte("episode"), 0o644))
+
+			if len(tt.existing) != 0 {
+				require.NoError(t, os.MkdirAll(filepath.Dir(trgPath), 0o755))
+				require.NoError(t, os.WriteFile(trgPath, []byte(tt.existing), 0o644))
+			}
+
+			got, err := CreateLink(srcPath, trgPath, LinkOptions{
+				Strategies: tt.strategies,
+				Overwrite:  tt.overwrite,
+				Dirs:       DefaultDirOptions,
+			})
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantStrategy, got)
+
+			content, err := os.ReadFile(trgPath)
+			assert.NoError(t, err)
+			assert.Equal(t, "episode", string(content))
+		})
+	}
+}
+
+func Test_CreateLink_ExistingLink(t *testing.T) {
+	dir := t.TempDir()
+	srcPath := filepath.Join(dir, "Series.Title.S01E01.mkv")
+	trgPath := filepath.Join(dir, "pre", "Series.Title.S01E01.mkv")
+
+	require.NoError(t, os.WriteFile(srcPath, []byte("episode"), 0o644))
+
+	opts := LinkOptions{Strategies: []string{domain.LinkStrategyHardlink}, Dirs: DefaultDirOptions}
+
+	got, err := CreateLink(srcPath, trgPath, opts)
+	require.NoError(t, err)
+	assert.Equal(t, domain.LinkStrategyHardlink, got)
+
+	got, err = CreateLink(srcPath, trgPath, opts)
+	assert.NoError(t, err)
+	assert.Equal(t, LinkExisting, got)
+}
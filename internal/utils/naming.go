@@ -0,0 +1,122 @@
+// Copyright (c) 2023 - 2024, nuxen and the seasonpackarr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package utils
+
+import (
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/nuxencs/seasonpackarr/internal/domain"
+	"github.com/nuxencs/seasonpackarr/pkg/errors"
+
+	"github.com/moistari/rls"
+)
+
+// FolderNameData is passed to folder templates, e.g. {{.Title}}.S{{printf "%02d" .Season}}-{{.Group}}.
+type FolderNameData struct {
+	Name       string
+	Title      string
+	Year       int
+	Season     int
+	Resolution string
+	Source     string
+	Service    string
+	HDR        string
+	Audio      string
+	Channels   string
+	Codec      string
+	Cut        string
+	Edition    string
+	Language   string
+	Other      string
+	Group      string
+}
+
+type folderRule struct {
+	re      *regexp.Regexp
+	replace string
+}
+
+// FolderNamer builds the season pack folder name from the announce name by executing the template, if one is set,
+// and applying the replace rules in order afterward. Releases of exempt groups are left untouched.
+type FolderNamer struct {
+	tmpl         *template.Template
+	rules        []folderRule
+	exemptGroups []string
+}
+
+// NewFolderNamer compiles the folder naming config. The client template takes precedence over the global one.
+func NewFolderNamer(naming domain.FolderNaming, clientTemplate string) (*FolderNamer, error) {
+	f := &FolderNamer{exemptGroups: naming.ExemptGroups}
+
+	tmpl := naming.Template
+	if len(clientTemplate) != 0 {
+		tmpl = clientTemplate
+	}
+
+	if len(tmpl) != 0 {
+		t, err := template.New("folder").Option("missingkey=error").Parse(tmpl)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not parse folder template: %s", tmpl)
+		}
+		f.tmpl = t
+	}
+
+	for _, rule := range naming.Rules {
+		re, err := regexp.Compile(rule.Match)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not compile folder rule: %s", rule.Match)
+		}
+		f.rules = append(f.rules, folderRule{re: re, replace: rule.Replace})
+	}
+
+	return f, nil
+}
+
+// Format returns the folder name for the given announce name.
+func (f *FolderNamer) Format(packName string) (string, error) {
+	r := rls.ParseString(packName)
+
+	for _, group := range f.exemptGroups {
+		if strings.EqualFold(group, r.Group) {
+			return packName, nil
+		}
+	}
+
+	if f.tmpl != nil {
+		var sb strings.Builder
+		if err := f.tmpl.Execute(&sb, newFolderNameData(packName, r)); err != nil {
+			return "", errors.Wrap(err, "could not execute folder template")
+		}
+		packName = sb.String()
+	}
+
+	for _, rule := range f.rules {
+		packName = rule.re.ReplaceAllString(packName, rule.replace)
+	}
+
+	return packName, nil
+}
+
+func newFolderNameData(packName string, r rls.Release) FolderNameData {
+	return FolderNameData{
+		Name:       packName,
+		Title:      r.Title,
+		Year:       r.Year,
+		Season:     r.Series,
+		Resolution: r.Resolution,
+		Source:     r.Source,
+		Service:    r.Collection,
+		HDR:        strings.Join(r.HDR, " "),
+		Audio:      strings.Join(r.Audio, " "),
+		Channels:   r.Channels,
+		Codec:      strings.Join(r.Codec, " "),
+		Cut:        strings.Join(r.Cut, " "),
+		Edition:    strings.Join(r.Edition, " "),
+		Language:   strings.Join(r.Language, " "),
+		Other:      strings.Join(r.Other, " "),
+		Group:      r.Group,
+	}
+}
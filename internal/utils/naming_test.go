@@ -0,0 +1,99 @@
+// Copyright (c) 2023 - 2024, nuxen and the seasonpackarr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package utils
+
+import (
+	"testing"
+
+	"github.com/nuxencs/seasonpackarr/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_FolderNamer_Format(t *testing.T) {
+	type args struct {
+		naming         domain.FolderNaming
+		clientTemplate string
+		packName       string
+	}
+	tests := []struct {
+		name string
+		args args
+		want string
+	}{
+		{
+			name: "default_rules",
+			args: args{
+				naming:   domain.FolderNaming{Rules: domain.DefaultFolderRules, ExemptGroups: domain.DefaultExemptGroups},
+				packName: "Rabbit Hole S01 1080p AMZN WEB-DL DDP 5.1 H.264-NTb",
+			},
+			want: "Rabbit.Hole.S01.1080p.AMZN.WEB-DL.DDP5.1.H.264-NTb",
+		},
+		{
+			name: "exempt_group",
+			args: args{
+				naming:   domain.FolderNaming{Rules: domain.DefaultFolderRules, ExemptGroups: []string{"ntb"}},
+				packName: "Rabbit Hole S01 1080p AMZN WEB-DL DDP 5.1 H.264-NTb",
+			},
+			want: "Rabbit Hole S01 1080p AMZN WEB-DL DDP 5.1 H.264-NTb",
+		},
+		{
+			name: "custom_rule",
+			args: args{
+				naming: domain.FolderNaming{Rules: append(append([]domain.FolderRule{}, domain.DefaultFolderRules...),
+					domain.FolderRule{Match: `\.H\.264`, Replace: ".H264"})},
+				packName: "Rabbit Hole S01 1080p AMZN WEB-DL DDP 5.1 H.264-NTb",
+			},
+			want: "Rabbit.Hole.S01.1080p.AMZN.WEB-DL.DDP5.1.H264-NTb",
+		},
+		{
+			name: "global_template",
+			args: args{
+				naming: domain.FolderNaming{
+					Template: `{{.Title}} S{{printf "%02d" .Season}} {{.Resolution}} {{.Service}} {{.Source}}-{{.Group}}`,
+					Rules:    domain.DefaultFolderRules,
+				},
+				packName: "Rabbit Hole S01 1080p AMZN WEB-DL DDP 5.1 H.264-NTb",
+			},
+			want: "Rabbit.Hole.S01.1080p.AMZN.WEB-DL-NTb",
+		},
+		{
+			name: "client_template_precedence",
+			args: args{
+				naming: domain.FolderNaming{
+					Template: `{{.Title}} S{{printf "%02d" .Season}}-{{.Group}}`,
+					Rules:    domain.DefaultFolderRules,
+				},
+				clientTemplate: `{{.Title}} ({{.Year}}) Season {{.Season}}`,
+				packName:       "Series Title 2022 S02 1080p ATVP WEB-DL DDP 5.1 Atmos H.264-RlsGrp",
+			},
+			want: "Series.Title.(2022).Season.2",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := NewFolderNamer(tt.args.naming, tt.args.clientTemplate)
+			require.NoError(t, err)
+
+			got, err := f.Format(tt.args.packName)
+			require.NoError(t, err)
+			assert.Equalf(t, tt.want, got, "Format(%s)", tt.args.packName)
+		})
+	}
+}
+
+func Test_NewFolderNamer_Invalid(t *testing.T) {
+	_, err := NewFolderNamer(domain.FolderNaming{Rules: []domain.FolderRule{{Match: `(`}}}, "")
+	assert.Error(t, err)
+
+	_, err = NewFolderNamer(domain.FolderNaming{}, `{{.Title`)
+	assert.Error(t, err)
+
+	f, err := NewFolderNamer(domain.FolderNaming{}, `{{.Unknown}}`)
+	require.NoError(t, err)
+
+	_, err = f.Format("Rabbit Hole S01 1080p AMZN WEB-DL DDP 5.1 H.264-NTb")
+	assert.Error(t, err)
+}
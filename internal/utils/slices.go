@@ -41,6 +41,22 @@ func EqualElements[T comparable](x, y []T) bool {
 	return true
 }
 
+func ContainsElements[T comparable](x, y []T) bool {
+	freqMap := make(map[T]int)
+	for _, i := range x {
+		freqMap[i]++
+	}
+
+	for _, i := range y {
+		if freqMap[i] == 0 {
+			return false
+		}
+		freqMap[i]--
+	}
+
+	return true
+}
+
 func SimplifyHDRSlice(hdrSlice []string) []string {
 	for i := range hdrSlice {
 		if strings.Contains(hdrSlice[i], "HDR") {
@@ -50,3 +66,53 @@ func SimplifyHDRSlice(hdrSlice []string) []string {
 
 	return hdrSlice
 }
+
+// StripAudioObjects removes the Atmos object audio tag, which is often left out of episode names, so DDP Atmos and
+// DDPA are treated as DDP.
+func StripAudioObjects(audioSlice []string) []string {
+	stripped := make([]string, 0, len(audioSlice))
+
+	for _, audio := range audioSlice {
+		switch audio {
+		case "Atmos":
+			continue
+		case "DDPA":
+			audio = "DDP"
+		}
+
+		stripped = append(stripped, audio)
+	}
+
+	return stripped
+}
+
+func SimplifyAudioSlice(audioSlice []string) []string {
+	simplified := make([]string, 0, len(audioSlice))
+
+	for _, audio := range StripAudioObjects(audioSlice) {
+		if strings.HasPrefix(audio, "DTS") {
+			audio = "DTS"
+		}
+
+		simplified = append(simplified, audio)
+	}
+
+	return simplified
+}
+
+func SimplifyCodecSlice(codecSlice []string) []string {
+	simplified := make([]string, 0, len(codecSlice))
+
+	for _, codec := range codecSlice {
+		switch codec {
+		case "x264", "AVC":
+			codec = "H.264"
+		case "x265", "HEVC":
+			codec = "H.265"
+		}
+
+		simplified = append(simplified, codec)
+	}
+
+	return simplified
+}
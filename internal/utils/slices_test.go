@@ -154,6 +154,51 @@ func Test_EqualElements(t *testing.T) {
 	}
 }
 
+func Test_ContainsElements(t *testing.T) {
+	tests := []struct {
+		name string
+		x    []string
+		y    []string
+		want bool
+	}{
+		{
+			name: "identical_elements",
+			x:    []string{"GERMAN", "DL"},
+			y:    []string{"DL", "GERMAN"},
+			want: true,
+		},
+		{
+			name: "subset",
+			x:    []string{"GERMAN", "DL"},
+			y:    []string{"GERMAN"},
+			want: true,
+		},
+		{
+			name: "superset",
+			x:    []string{"GERMAN"},
+			y:    []string{"GERMAN", "DL"},
+			want: false,
+		},
+		{
+			name: "different_elements",
+			x:    []string{"GERMAN"},
+			y:    []string{"NORDiC"},
+			want: false,
+		},
+		{
+			name: "empty_subset",
+			x:    []string{"GERMAN"},
+			y:    []string{},
+			want: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equalf(t, tt.want, ContainsElements(tt.x, tt.y), "ContainsElements(%v, %v)", tt.x, tt.y)
+		})
+	}
+}
+
 func Test_SimplifyHDRSlice(t *testing.T) {
 	tests := []struct {
 		name  string
@@ -187,3 +232,110 @@ func Test_SimplifyHDRSlice(t *testing.T) {
 		})
 	}
 }
+
+func Test_StripAudioObjects(t *testing.T) {
+	tests := []struct {
+		name  string
+		input []string
+		want  []string
+	}{
+		{
+			name:  "contains_atmos",
+			input: []string{"DDP", "Atmos"},
+			want:  []string{"DDP"},
+		},
+		{
+			name:  "contains_DDPA",
+			input: []string{"DDPA"},
+			want:  []string{"DDP"},
+		},
+		{
+			name:  "keeps_DTS_variants",
+			input: []string{"DTS-HD.MA", "TrueHD", "Atmos"},
+			want:  []string{"DTS-HD.MA", "TrueHD"},
+		},
+		{
+			name:  "empty_slice",
+			input: []string{},
+			want:  []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equalf(t, tt.want, StripAudioObjects(tt.input), "StripAudioObjects(%v)", tt.input)
+		})
+	}
+}
+
+func Test_SimplifyAudioSlice(t *testing.T) {
+	tests := []struct {
+		name  string
+		input []string
+		want  []string
+	}{
+		{
+			name:  "contains_atmos",
+			input: []string{"DDP", "Atmos"},
+			want:  []string{"DDP"},
+		},
+		{
+			name:  "contains_DDPA",
+			input: []string{"DDPA"},
+			want:  []string{"DDP"},
+		},
+		{
+			name:  "contains_DTS_variants",
+			input: []string{"DTS-HD.MA", "DTS-X"},
+			want:  []string{"DTS", "DTS"},
+		},
+		{
+			name:  "no_simplification",
+			input: []string{"AAC", "TrueHD"},
+			want:  []string{"AAC", "TrueHD"},
+		},
+		{
+			name:  "empty_slice",
+			input: []string{},
+			want:  []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equalf(t, tt.want, SimplifyAudioSlice(tt.input), "SimplifyAudioSlice(%v)", tt.input)
+		})
+	}
+}
+
+func Test_SimplifyCodecSlice(t *testing.T) {
+	tests := []struct {
+		name  string
+		input []string
+		want  []string
+	}{
+		{
+			name:  "h264_variants",
+			input: []string{"x264", "AVC", "H.264"},
+			want:  []string{"H.264", "H.264", "H.264"},
+		},
+		{
+			name:  "h265_variants",
+			input: []string{"x265", "HEVC", "H.265"},
+			want:  []string{"H.265", "H.265", "H.265"},
+		},
+		{
+			name:  "no_simplification",
+			input: []string{"VP9"},
+			want:  []string{"VP9"},
+		},
+		{
+			name:  "empty_slice",
+			input: []string{},
+			want:  []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equalf(t, tt.want, SimplifyCodecSlice(tt.input), "SimplifyCodecSlice(%v)", tt.input)
+		})
+	}
+}